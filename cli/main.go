@@ -1,11 +1,15 @@
 package main
 
 import (
+	"bufio"
 	conf "ffgh/config"
+	"ffgh/editor"
 	"ffgh/fzf"
 	"ffgh/gh"
 	"ffgh/storage"
 	"ffgh/sync"
+	"ffgh/theme"
+	"ffgh/ui"
 	"ffgh/util"
 	"ffgh/xbar"
 	"flag"
@@ -13,7 +17,9 @@ import (
 	"io"
 	"log"
 	"os"
+	"os/exec"
 	"path"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -23,14 +29,29 @@ import (
 
 const (
 	commandAddNote            = "add-note"
+	commandAddTag             = "add-tag"
 	commandCycleNote          = "cycle-note"
+	commandCycleSort          = "cycle-sort"
 	commandCycleView          = "cycle-view-mode"
+	commandEditNote           = "edit-note"
+	commandExportState        = "export-state"
+	commandFilterTag          = "filter-tag"
 	commandFzf                = "fzf"
+	commandHelp               = "help"
+	commandImportState        = "import-state"
 	commandShowCompactSummary = "show-compact-summary"
 	commandMarkOpen           = "mark-open"
 	commandMarkMute           = "mark-mute"
+	commandMarkUnread         = "mark-unread"
+	commandOpen               = "open"
+	commandPin                = "pin"
+	commandRunBinding         = "run-binding"
+	commandRemoveTag          = "remove-tag"
 	commandShowPr             = "show-pr"
 	commandSync               = "sync"
+	commandToggleDiff         = "toggle-diff"
+	commandUi                 = "ui"
+	commandUndo               = "undo"
 )
 const (
 	// outOfSyncPeriod says how long do we wait for sync before considering the state out of sync.
@@ -40,14 +61,29 @@ const (
 func main() {
 	commands := []string{
 		commandAddNote,
+		commandAddTag,
 		commandCycleNote,
+		commandCycleSort,
 		commandCycleView,
+		commandEditNote,
+		commandExportState,
+		commandFilterTag,
 		commandFzf,
+		commandHelp,
+		commandImportState,
 		commandMarkMute,
 		commandMarkOpen,
+		commandMarkUnread,
+		commandOpen,
+		commandPin,
+		commandRemoveTag,
+		commandRunBinding,
 		commandShowCompactSummary,
 		commandShowPr,
 		commandSync,
+		commandToggleDiff,
+		commandUi,
+		commandUndo,
 	}
 	flag.Usage = func() {
 		fmt.Printf("Utility to synchronize and display state of GitHub PRs.\n")
@@ -82,7 +118,6 @@ func main() {
 		}
 	}
 	command := flag.Args()[0]
-	color.NoColor = false
 	config := conf.GetDefaultConfig()
 	if path := options.configPath; path != "" {
 		log.Printf("Read config from %s", options.configPath)
@@ -93,29 +128,75 @@ func main() {
 			log.Printf("Reading config failed, using default: %s", err)
 		}
 	}
+	// The output is colored even if it's not a terminal, since it's read by fzf, unless NO_COLOR is set.
+	color.NoColor = os.Getenv("NO_COLOR") != ""
+	if err := theme.Set(config.Theme.Base, config.Theme.Styles); err != nil {
+		log.Printf("Bad theme, using default: %s", err)
+	}
 	log.Printf("Run command: %s", command)
 	storage := storage.NewFileStorage()
 	storage.PrsStatePath = path.Join(options.statePath, storage.PrsStatePath)
 	storage.UserStatePath = path.Join(options.statePath, storage.UserStatePath)
+	storage.JournalPath = path.Join(options.statePath, storage.JournalPath)
+	storage.CommentsPath = path.Join(options.statePath, storage.CommentsPath)
+	storage.LoginPath = path.Join(options.statePath, storage.LoginPath)
+	storage.DiffsPath = path.Join(options.statePath, storage.DiffsPath)
+	if config.Login == "" {
+		if login, err := storage.GetLogin(); err != nil {
+			log.Printf("Could not read login: %s", err)
+		} else {
+			config.Login = login
+		}
+	}
 	if err := func() error {
 		if command == commandSync {
 			return runCommandSync(config, storage)
+		} else if command == commandUi {
+			return runCommandUi(options.statePath, options.configPath, config, storage)
+		} else if command == commandHelp {
+			return runCommandHelp(config)
+		} else if command == commandRunBinding {
+			return runCommandRunBinding(config, storage)
 		} else if command == commandFzf {
 			return runCommandFzf(config, storage)
 		} else if command == commandShowCompactSummary {
 			return runCommandShowCompactSummary(storage)
 		} else if command == commandShowPr {
-			return runCommandShowPr(storage)
+			return runCommandShowPr(config, storage)
 		} else if command == commandMarkOpen {
 			return runCommandMarkOpen(storage)
+		} else if command == commandOpen {
+			return runCommandOpen(config, storage)
+		} else if command == commandMarkUnread {
+			return runCommandMarkUnread(storage)
+		} else if command == commandPin {
+			return runCommandPin(storage)
 		} else if command == commandMarkMute {
 			return runCommandMarkMute(storage)
 		} else if command == commandAddNote {
 			return runCommandAddNote(storage)
+		} else if command == commandEditNote {
+			return runCommandEditNote(storage)
+		} else if command == commandAddTag {
+			return runCommandAddTag(storage)
+		} else if command == commandRemoveTag {
+			return runCommandRemoveTag(storage)
+		} else if command == commandFilterTag {
+			return runCommandFilterTag(storage)
 		} else if command == commandCycleView {
-			return runCommandCycleView(storage)
+			return runCommandCycleView(config, storage)
+		} else if command == commandCycleSort {
+			return runCommandCycleSort(storage)
 		} else if command == commandCycleNote {
 			return runCommandCycleNote(config, storage)
+		} else if command == commandToggleDiff {
+			return runCommandToggleDiff(storage)
+		} else if command == commandUndo {
+			return runCommandUndo(storage)
+		} else if command == commandExportState {
+			return runCommandExportState(storage)
+		} else if command == commandImportState {
+			return runCommandImportState(storage)
 		} else {
 			return fmt.Errorf("unknown command: %s", command)
 		}
@@ -161,11 +242,92 @@ func runCommandFzf(config conf.Config, storage storage.Storage) error {
 	} else {
 		syncStr = "X not synced"
 	}
-	fmt.Fprintf(out, "%s | %s\n", syncStr, userState.Settings.ViewMode)
+	sortMode := userState.Settings.SortMode
+	if sortMode == "" {
+		sortMode = fzf.SortModeQuery
+	}
+	viewMode := fzf.FindView(userState.Settings.ViewMode, fzf.GetViews(config)).Name
+	header := fmt.Sprintf("%s | %s | sort: %s", syncStr, viewMode, sortMode)
+	if tag := userState.Settings.TagFilter; tag != "" {
+		header += fmt.Sprintf(" | #%s", tag)
+	}
+	fmt.Fprintln(out, header)
 	fzf.FprintPullRequests(out, int(terminalWidth), prs, userState, config)
 	return nil
 }
 
+func runCommandUi(statePath, configPath string, config conf.Config, storage storage.Storage) error {
+	fs := flag.NewFlagSet(commandUi, flag.ExitOnError)
+	builtin := fs.Bool("builtin", false, "Use the built-in picker instead of fzf. The built-in picker is used anyway if fzf is not installed.")
+	fs.Parse(flag.Args()[1:])
+	executable, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("cannot figure the ffgh executable: %w", err)
+	}
+	bin := strings.Join([]string{
+		ui.ShellQuote(executable),
+		"-d", ui.ShellQuote(statePath),
+		"-c", ui.ShellQuote(configPath),
+	}, " ")
+	bindings, err := ui.BuildBindings(config.KeyBindings)
+	if err != nil {
+		return fmt.Errorf("bad key bindings: %w", err)
+	}
+	launcher := ui.New(bin, bindings, func(urls []string) error {
+		return markAndOpen(config, storage, urls, "")
+	})
+	launcher.Builtin = *builtin
+	return launcher.Run()
+}
+
+func runCommandHelp(config conf.Config) error {
+	bindings, err := ui.BuildBindings(config.KeyBindings)
+	if err != nil {
+		return fmt.Errorf("bad key bindings: %w", err)
+	}
+	ui.FprintHelp(os.Stdout, bindings, fzf.GetViews(config))
+	return nil
+}
+
+func runCommandRunBinding(config conf.Config, storage storage.Storage) error {
+	if len(flag.Args()) < 2 {
+		return fmt.Errorf("expected key of the binding")
+	}
+	key := flag.Args()[1]
+	command := ""
+	for _, kb := range config.KeyBindings {
+		if kb.Key == key {
+			command = kb.Command
+		}
+	}
+	if command == "" {
+		return fmt.Errorf("no command bound to key %s", key)
+	}
+	urls, err := readUrls(flag.Args()[2:])
+	if err != nil {
+		return err
+	}
+	prs, err := storage.GetPullRequests()
+	if err != nil {
+		return fmt.Errorf("storage failed: %w", err)
+	}
+	for _, url := range urls {
+		for _, pr := range prs {
+			if pr.URL != url {
+				continue
+			}
+			expanded := ui.ExpandCommand(command, pr)
+			log.Printf("Run %s", expanded)
+			cmd := exec.Command("sh", "-c", expanded)
+			cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+			if err := cmd.Run(); err != nil {
+				return fmt.Errorf("error while running %s: %w", expanded, err)
+			}
+		}
+	}
+	return nil
+}
+
 func runCommandShowCompactSummary(storage storage.Storage) error {
 	prs, userState, err := loadState(storage)
 	if err != nil {
@@ -180,7 +342,7 @@ func runCommandShowCompactSummary(storage storage.Storage) error {
 	return nil
 }
 
-func runCommandShowPr(storage storage.Storage) error {
+func runCommandShowPr(config conf.Config, storage storage.Storage) error {
 	if len(flag.Args()) < 2 {
 		return fmt.Errorf("expected url to identify pr")
 	}
@@ -189,7 +351,29 @@ func runCommandShowPr(storage storage.Storage) error {
 	if err != nil {
 		return fmt.Errorf("storage failed: %w", err)
 	}
-	fzf.FprintShowPullRequest(os.Stdout, prUrl, prs, userState)
+	i := slices.IndexFunc(prs, func(pr gh.PullRequest) bool { return pr.URL == prUrl })
+	if i >= 0 && userState.Settings.PreviewMode == fzf.PreviewDiff {
+		// The error is shown in the preview, followed by the PR details, as the log is not shown in fzf.
+		diff, err := sync.GetDiff(storage, prs[i])
+		if err == nil {
+			fzf.FprintDiff(os.Stdout, diff)
+			return nil
+		}
+		fmt.Printf("Could not get the diff: %s\n\n", err)
+	}
+	comments := []gh.Comment{}
+	if i >= 0 {
+		if comments, err = sync.GetComments(storage, prs[i]); err != nil {
+			log.Printf("Could not get comments, show the PR without them: %s", err)
+		}
+	}
+	// fzf sets the width of the preview window for the preview command.
+	width, err := strconv.Atoi(os.Getenv("FZF_PREVIEW_COLUMNS"))
+	if err != nil {
+		log.Printf("Could not figure preview width, use default: %s", err)
+		width = 80
+	}
+	fzf.FprintShowPullRequest(os.Stdout, width, prUrl, prs, userState, comments, config.Login)
 	return nil
 }
 
@@ -197,86 +381,334 @@ func runCommandMarkOpen(storage storage.Storage) error {
 	fs := flag.NewFlagSet(commandMarkOpen, flag.ExitOnError)
 	fs.Usage = func() {
 		fmt.Println("" +
-			"Mark URL as opened (visited). The command returns exit code 1 if the file was visited already and the" +
-			" command was no-op. This is to facilitate chaining commands with fzf bindings.")
+			"Mark URLs as opened (visited). The URLs are read from stdin if not passed as arguments. The command" +
+			" returns exit code 1 if all the URLs were visited already and the command was no-op. This is to" +
+			" facilitate chaining commands with fzf bindings.")
 		fs.PrintDefaults()
 	}
-	exitErrorIfMarked := fs.Bool("e", false, fmt.Sprintf("Exit with error code 1 if the file is already marked as opened. This is useful to conditionally chain %s and other commands.", commandMarkOpen))
+	exitErrorIfMarked := fs.Bool("e", false, fmt.Sprintf("Exit with error code 1 if the URLs are already marked as opened. This is useful to conditionally chain %s and other commands.", commandMarkOpen))
 	fs.Parse(flag.Args()[1:])
 	log.Printf("Flag -e=%t", *exitErrorIfMarked)
-	if len(fs.Args()) < 1 {
-		return fmt.Errorf("expected url to mark")
+	urls, err := readUrls(fs.Args())
+	if err != nil {
+		return err
 	}
-	url := fs.Args()[0]
-	marked, err := storage.MarkUrlAsOpened(url)
+	marked, err := storage.MarkUrlsAsOpened(urls)
 	if err != nil {
 		return err
 	}
-	if !marked && *exitErrorIfMarked {
-		return fmt.Errorf("URL already marked as opened, doing nothing: %s", url)
+	if marked == 0 && *exitErrorIfMarked {
+		return fmt.Errorf("URLs already marked as opened, doing nothing: %s", strings.Join(urls, ", "))
 	}
 	return nil
 }
 
+func runCommandOpen(config conf.Config, storage storage.Storage) error {
+	fs := flag.NewFlagSet(commandOpen, flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Println("Mark URLs as opened and open them in the browser. The URLs are read from stdin if not passed as arguments.")
+		fs.PrintDefaults()
+	}
+	target := fs.String("t", "", "The PR page to open, like files, checks or commits. The conversation by default.")
+	fs.Parse(flag.Args()[1:])
+	urls, err := readUrls(fs.Args())
+	if err != nil {
+		return err
+	}
+	return markAndOpen(config, storage, urls, *target)
+}
+
+func markAndOpen(config conf.Config, storage storage.Storage, urls []string, target string) error {
+	targetUrls := []string{}
+	for _, url := range urls {
+		targetUrl, err := ui.TargetUrl(url, target, config.UrlTargets)
+		if err != nil {
+			return err
+		}
+		targetUrls = append(targetUrls, targetUrl)
+	}
+	if _, err := storage.MarkUrlsAsOpened(urls); err != nil {
+		return err
+	}
+	return ui.OpenUrls(config.Opener, targetUrls)
+}
+
+func runCommandMarkUnread(storage storage.Storage) error {
+	urls, err := readUrls(flag.Args()[1:])
+	if err != nil {
+		return err
+	}
+	return storage.MarkUrlsAsUnread(urls)
+}
+
 func runCommandMarkMute(storage storage.Storage) error {
-	if len(flag.Args()) < 2 {
-		return fmt.Errorf("expected url to mark")
+	urls, err := readUrls(flag.Args()[1:])
+	if err != nil {
+		return err
 	}
-	url := flag.Args()[1]
-	return storage.MarkUrlAsMuted(url)
+	return storage.MarkUrlsAsMuted(urls)
+}
+
+func runCommandPin(storage storage.Storage) error {
+	urls, err := readUrls(flag.Args()[1:])
+	if err != nil {
+		return err
+	}
+	return storage.MarkUrlsAsPinned(urls)
 }
 
 func runCommandAddNote(storage storage.Storage) error {
 	if len(flag.Args()) < 3 {
-		return fmt.Errorf("expected URL to mark and file with note")
+		return fmt.Errorf("expected URLs to mark and file with note")
 	}
-	url := flag.Args()[1]
-	fileWithNote := flag.Args()[2]
+	urls := flag.Args()[1 : len(flag.Args())-1]
+	fileWithNote := flag.Args()[len(flag.Args())-1]
 	b, err := os.ReadFile(fileWithNote)
 	if err != nil {
 		return fmt.Errorf("failed to read note: %w", err)
 	}
 	note := strings.TrimSpace(string(b))
+	notes := make(map[string]string)
+	for _, url := range urls {
+		notes[url] = note
+	}
+	return storage.AddNotes(notes)
+}
+
+func runCommandEditNote(storage storage.Storage) error {
+	if len(flag.Args()) < 2 {
+		return fmt.Errorf("expected URL to edit note for")
+	}
+	url := flag.Args()[1]
+	prs, s, err := loadState(storage)
+	if err != nil {
+		return fmt.Errorf("storage failed: %w", err)
+	}
+	// The lines that are not PRs, like group headers, and the unknown URLs have no notes.
+	i := slices.IndexFunc(prs, func(pr gh.PullRequest) bool { return pr.URL == url })
+	if i < 0 {
+		return fmt.Errorf("no such pr with url: %s", url)
+	}
+	pr := prs[i]
+	header := []string{
+		fmt.Sprintf("%s (#%d) %s", pr.Repository.NameWithOwner, pr.Number, pr.Title),
+		url,
+		"These comment lines are ignored. Save an empty note to remove it.",
+	}
+	currNote := s.PerUrl[url].Note
+	note, err := editor.Edit(header, currNote)
+	if err != nil {
+		return err
+	}
+	if note == currNote {
+		log.Printf("Note not changed for %s", url)
+		return nil
+	}
 	return storage.AddNote(url, note)
 }
 
-func runCommandCycleView(storage storage.Storage) error {
+func runCommandAddTag(storage storage.Storage) error {
+	if len(flag.Args()) < 2 {
+		return fmt.Errorf("expected tag and URLs to tag")
+	}
+	tag := flag.Args()[1]
+	urls, err := readUrls(flag.Args()[2:])
+	if err != nil {
+		return err
+	}
+	return storage.AddTag(urls, tag)
+}
+
+func runCommandRemoveTag(storage storage.Storage) error {
+	if len(flag.Args()) < 2 {
+		return fmt.Errorf("expected tag and URLs to untag")
+	}
+	tag := flag.Args()[1]
+	urls, err := readUrls(flag.Args()[2:])
+	if err != nil {
+		return err
+	}
+	return storage.RemoveTag(urls, tag)
+}
+
+func runCommandFilterTag(storage storage.Storage) error {
+	s, err := storage.GetUserState()
+	if err != nil {
+		return fmt.Errorf("error when setting tag filter: %w", err)
+	}
+	tag := ""
+	if len(flag.Args()) > 1 {
+		tag = flag.Args()[1]
+	}
+	log.Printf("Set tag filter to '%s'", tag)
+	s.Settings.TagFilter = tag
+	return storage.WriteUserState(s)
+}
+
+func runCommandCycleView(config conf.Config, storage storage.Storage) error {
 	s, err := storage.GetUserState()
 	if err != nil {
 		return fmt.Errorf("error when running cycle view: %w", err)
 	}
-	viewMode := s.Settings.ViewMode
-	s.Settings.ViewMode = fzf.CycleViewMode(viewMode)
-	log.Printf("Turn view mode %s to %s", viewMode, s.Settings.ViewMode)
-	if err = storage.WriteUserState(s); err != nil {
+	views := fzf.GetViews(config)
+	viewMode := fzf.FindView(s.Settings.ViewMode, views).Name
+	newView := fzf.FindView(fzf.CycleViewMode(viewMode, views), views)
+	log.Printf("Turn view mode %s to %s", viewMode, newView.Name)
+	// The sort mode picked by the user is kept, unless the view sets one.
+	sortMode := s.Settings.SortMode
+	if newView.Sort != "" {
+		sortMode = newView.Sort
+	}
+	if err = storage.SetViewMode(newView.Name, sortMode); err != nil {
 		return fmt.Errorf("error when running cycle view: %w", err)
 	}
 	return nil
 }
 
-func runCommandCycleNote(config conf.Config, storage storage.Storage) error {
-	if len(flag.Args()) < 2 {
-		return fmt.Errorf("expected URL to change note for")
+func runCommandCycleSort(storage storage.Storage) error {
+	s, err := storage.GetUserState()
+	if err != nil {
+		return fmt.Errorf("error when running cycle sort: %w", err)
+	}
+	sortMode := s.Settings.SortMode
+	if sortMode == "" {
+		sortMode = fzf.SortModeQuery
+	}
+	newSortMode := fzf.CycleSortMode(sortMode)
+	log.Printf("Turn sort mode %s to %s", sortMode, newSortMode)
+	if err = storage.SetSortMode(newSortMode); err != nil {
+		return fmt.Errorf("error when running cycle sort: %w", err)
 	}
+	return nil
+}
+
+func runCommandCycleNote(config conf.Config, storage storage.Storage) error {
 	if len(config.Annotations) == 0 {
 		return fmt.Errorf("no annotations set in config")
 	}
-	url := flag.Args()[1]
-	log.Printf("Cycle note for url %s", url)
+	urls, err := readUrls(flag.Args()[1:])
+	if err != nil {
+		return err
+	}
 	s, err := storage.GetUserState()
 	if err != nil {
-		return fmt.Errorf("error when running cycle view: %w", err)
+		return fmt.Errorf("error when running cycle note: %w", err)
 	}
 
-	currNote := ""
-	if prState, ok := s.PerUrl[url]; ok {
-		currNote = prState.Note
-	}
 	annotations := append([]string{}, config.Annotations...)
 	annotations = append(annotations, "") // Add empty note at the end of the cycle
-	newNote := util.Cycle(currNote, annotations)
-	log.Printf("Old note '%s', new note '%s'", currNote, newNote)
-	return storage.AddNote(url, newNote)
+	notes := make(map[string]string)
+	for _, url := range urls {
+		currNote := ""
+		if prState, ok := s.PerUrl[url]; ok {
+			currNote = prState.Note
+		}
+		newNote := util.Cycle(currNote, annotations)
+		log.Printf("Cycle note for url %s, old note '%s', new note '%s'", url, currNote, newNote)
+		notes[url] = newNote
+	}
+	return storage.AddNotes(notes)
+}
+
+func runCommandToggleDiff(storage storage.Storage) error {
+	s, err := storage.GetUserState()
+	if err != nil {
+		return fmt.Errorf("error when toggling diff: %w", err)
+	}
+	mode := fzf.TogglePreviewMode(s.Settings.PreviewMode)
+	log.Printf("Turn preview mode %q to %s", s.Settings.PreviewMode, mode)
+	if err = storage.SetPreviewMode(mode); err != nil {
+		return fmt.Errorf("error when toggling diff: %w", err)
+	}
+	return nil
+}
+
+func runCommandUndo(storage storage.Storage) error {
+	entry, err := storage.Undo()
+	if err != nil {
+		return err
+	}
+	if entry == nil {
+		log.Printf("Nothing to undo")
+	} else {
+		log.Printf("Undone %s of %d PRs", entry.Action, len(entry.Changes))
+	}
+	return nil
+}
+
+// readUrls returns the URLs from the arguments, or from stdin if there are no arguments. On stdin, only the first
+// tab-separated field of each line is used, so the output of fzf can be piped directly.
+func readUrls(args []string) ([]string, error) {
+	if len(args) > 0 {
+		// The lines that are not PRs, like group headers, can be passed by fzf bindings and are skipped.
+		return slices.DeleteFunc(slices.Clone(args), func(a string) bool { return a == fzf.NoUrl }), nil
+	}
+	if info, err := os.Stdin.Stat(); err != nil || info.Mode()&os.ModeCharDevice != 0 {
+		return nil, fmt.Errorf("expected URLs as arguments or on stdin")
+	}
+	urls := []string{}
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		url, _, _ := strings.Cut(strings.TrimSpace(scanner.Text()), "\t")
+		if url != "" && url != fzf.NoUrl {
+			urls = append(urls, url)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error while reading URLs from stdin: %w", err)
+	}
+	if len(urls) == 0 {
+		return nil, fmt.Errorf("expected URLs as arguments or on stdin")
+	}
+	log.Printf("Read %d URLs from stdin", len(urls))
+	return urls, nil
+}
+
+func runCommandExportState(st storage.Storage) error {
+	fs := flag.NewFlagSet(commandExportState, flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Println("Export the user state (read marks, notes, mutes) as a portable JSON bundle.")
+		fs.PrintDefaults()
+	}
+	outPath := fs.String("o", "", "File to write the bundle to. Stdout by default.")
+	fs.Parse(flag.Args()[1:])
+	s, err := st.GetUserState()
+	if err != nil {
+		return fmt.Errorf("error when exporting state: %w", err)
+	}
+	out := os.Stdout
+	if *outPath != "" {
+		f, err := os.Create(*outPath)
+		if err != nil {
+			return fmt.Errorf("error when exporting state: %w", err)
+		}
+		defer f.Close()
+		out = f
+	}
+	log.Printf("Export state of %d PRs", len(s.PerUrl))
+	return storage.FprintStateBundle(out, s)
+}
+
+func runCommandImportState(st storage.Storage) error {
+	if len(flag.Args()) < 2 {
+		return fmt.Errorf("expected file with the state bundle")
+	}
+	f, err := os.Open(flag.Args()[1])
+	if err != nil {
+		return fmt.Errorf("error when importing state: %w", err)
+	}
+	defer f.Close()
+	bundle, err := storage.ReadStateBundle(f)
+	if err != nil {
+		return fmt.Errorf("error when importing state: %w", err)
+	}
+	s, err := st.GetUserState()
+	if err != nil {
+		return fmt.Errorf("error when importing state: %w", err)
+	}
+	log.Printf("Import state of %d PRs exported at %s", len(bundle.UserState.PerUrl), bundle.ExportedAt)
+	s.Merge(bundle.UserState)
+	return st.WriteUserState(s)
 }
 
 func loadState(storage storage.Storage) ([]gh.PullRequest, *storage.UserState, error) {
@@ -17,6 +17,96 @@ type Config struct {
 	AttributionOrder []string `yaml:"attribution_order"`
 	// Annotations are standard notest that the user can easily cycle through instead of adding the note by hand.
 	Annotations []string `yaml:"annotations"`
+	// KeyBindings override or extend the default key bindings of the UI.
+	KeyBindings []KeyBinding `yaml:"keybindings"`
+	// Opener is the shell command that opens URLs. By default it is `open` on macOS and `xdg-open` otherwise.
+	Opener string `yaml:"opener"`
+	// LineFormat is the layout of the list line. The default layout is used if not set.
+	LineFormat LineFormat `yaml:"line_format"`
+	Theme      Theme      `yaml:"theme"`
+	// GroupBy groups the list under headers per query or per repo. Not grouped by default.
+	GroupBy string `yaml:"group_by"`
+	// Views are the named views that the user cycles through. If empty, the built-in views are used.
+	Views []View `yaml:"views"`
+	// UrlTargets map names of the PR pages to suffixes appended to the PR URL, like "files" to "/files".
+	UrlTargets map[string]string `yaml:"url_targets"`
+	// RepoAliases map repository names, with or without owner, to the names shown in the list.
+	RepoAliases map[string]string `yaml:"repo_aliases"`
+	// Columns toggle the optional columns shown at the right edge of the list.
+	Columns Columns `yaml:"columns"`
+	// Login is the GitHub login of the user, used to mark the user's own PRs and to not flag the user's own activity.
+	// By default, the login of the user authenticated in gh is resolved by sync.
+	Login string `yaml:"login"`
+}
+
+// ActionNone disables the default key binding.
+const ActionNone = "none"
+
+type KeyBinding struct {
+	// Key is the fzf key name, like ctrl-r or alt-g.
+	Key string `yaml:"key"`
+	// Action is the name of a built-in action.
+	Action string `yaml:"action"`
+	// Command is a shell command run for each of the selected PRs, instead of a built-in action. The {url}, {repo}
+	// and {number} placeholders are replaced with the values of the PR.
+	Command string `yaml:"command"`
+	// Target is the name of the PR page opened by the open action, like "files".
+	Target      string `yaml:"target"`
+	Description string `yaml:"description"`
+}
+
+const (
+	MuteShow = "show"
+	MuteLast = "last"
+	MuteHide = "hide"
+	MuteOnly = "only"
+)
+
+const (
+	FlagNew      = "new"
+	FlagUpdated  = "updated"
+	FlagComments = "comments"
+)
+
+type Theme struct {
+	// Base is the name of a built-in theme: dark (default), light or monochrome.
+	Base string `yaml:"base"`
+	// Styles override the styles of the base theme per role, like "new: green bold".
+	Styles map[string]string `yaml:"styles"`
+}
+
+type Columns struct {
+	// Author shows who opened the PR, dimmed for bots and marked as "me" for the user's own PRs.
+	Author bool `yaml:"author"`
+	// Age shows how long ago the PR was opened.
+	Age bool `yaml:"age"`
+	// Updated shows how long ago the PR was last updated.
+	Updated bool `yaml:"updated"`
+}
+
+// LineFormat holds text/template templates of the left part of the list line, and of the right part that is aligned to
+// the right edge of the terminal.
+type LineFormat struct {
+	Left  string `yaml:"left"`
+	Right string `yaml:"right"`
+}
+
+// View is a named set of filters and the sort order of the list. The empty filters match all PRs.
+type View struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description"`
+	// Queries are the query names of the PRs to show.
+	Queries []string `yaml:"queries"`
+	// Flags show only the PRs with any of the flags: new, updated, comments.
+	Flags []string `yaml:"flags"`
+	// Repos are the repository names, with or without owner.
+	Repos []string `yaml:"repos"`
+	// Tags show only the PRs with any of the tags.
+	Tags []string `yaml:"tags"`
+	// Mute is how to show the muted PRs: show (default), last, hide or only.
+	Mute string `yaml:"mute"`
+	// Sort is the sort mode set when switching to the view.
+	Sort string `yaml:"sort"`
 }
 
 type Query struct {
@@ -61,6 +151,61 @@ display_order:
   - "Author"
 annotations:
   - Approved
+# Key bindings override the default bindings of the same key, or add new ones. The 'action' is one of: open,
+# mark-read, mark-read-or-mute, mark-unread, mute, pin, cycle-note, edit-note, cycle-view, cycle-sort, toggle-diff,
+# undo, help, none. Instead of 'action', 'command' runs a shell command for each selected PR, with {url}, {repo} and
+# {number} placeholders.
+# keybindings:
+#   - key: "alt-c"
+#     command: "gh pr checkout {number} --repo {repo}"
+#     description: "Checkout the PR"
+#   - key: "ctrl-y"
+#     command: "echo -n {url} | pbcopy"
+#     description: "Copy URL"
+# Line format is the text/template layout of the list line, with the 'right' part aligned to the right edge. The
+# fields are: .Flags .Repo .OwnerRepo .Owner .RepoWidth .ShortLabel .Query .Number .Title .Author .Age .UpdatedAgo
+# .Labels .Comments .NewComments .Note .Tags .Columns .URL. The functions 'left N', 'right N' and 'trunc N' pad or cut
+# values to width N.
+# line_format:
+#   left: '{{.Flags}} {{.OwnerRepo | left 30}} #{{.Number | left 5}} {{.Title | trunc 60}} {{.Author}}'
+#   right: '{{with .Note}} {{.}}{{end}} {{.UpdatedAgo}}'
+# Theme is one of the built-in themes (dark, light, monochrome) with optional style overrides per role. The roles are:
+# new, updated, comments, pinned, note, tags, muted, repo, title, author, details, header, timestamp, own, bot, heading,
+# code, link, added, removed, hunk, cursor, selected. A style is a list of: black, red, green, yellow, blue, magenta,
+# cyan, white, their hi-, bg- and bg-hi- variants, bold, faint, italic, underline, reverse. Set the NO_COLOR environment variable to disable colors.
+# theme:
+#   base: "light"
+#   styles:
+#     new: "green bold underline"
+# Group by 'query' or 'repo' shows the PRs under a header per query or repository.
+# group_by: "query"
+# Views replace the built-in view modes (regular, mute-top, hide-mute) cycled with cycle-view-mode. Each view can
+# filter by 'queries', 'flags' (new, updated, comments), 'repos' and 'tags', set how to show muted PRs with 'mute'
+# (show, last, hide, only), and set the 'sort' mode.
+# views:
+#   - name: "inbox"
+#     flags: ["new", "updated", "comments"]
+#     mute: "hide"
+#     sort: "requested"
+#   - name: "all"
+#     mute: "last"
+# Opener is the command that opens the URLs. By default 'open' on macOS and 'xdg-open' otherwise.
+# opener: "firefox"
+# URL targets are the PR pages that the open action can open with 'target', in addition to conversation, files,
+# checks and commits.
+# url_targets:
+#   reviews: "/reviews"
+# Repo aliases are the names shown in the list for the repositories, by name with or without owner. Without an alias,
+# the owner is shown only when repositories of different owners have the same name.
+# repo_aliases:
+#   "my-org/very-long-repository-name": "vlrn"
+# Columns are shown at the right edge of the list: the 'author' of the PR, with bots dimmed and the PRs of 'login'
+# shown as "me", the 'age' since the PR was opened and the time since it was 'updated'.
+# login: "my-github-login"
+# columns:
+#   author: true
+#   age: true
+#   updated: true
 `
 
 func GetDefaultConfig() Config {
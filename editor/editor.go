@@ -0,0 +1,80 @@
+package editor
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+const (
+	commentPrefix = "#"
+	defaultEditor = "vim"
+)
+
+// Edit opens the text in the user's editor ($VISUAL, $EDITOR or vim) and returns the edited text. The header lines are
+// prepended to the text as comments, and stripped from the result.
+func Edit(header []string, text string) (string, error) {
+	f, err := os.CreateTemp("", "ffgh-*.txt")
+	if err != nil {
+		return "", fmt.Errorf("error while creating temp file: %w", err)
+	}
+	defer os.Remove(f.Name())
+	content := ""
+	for _, h := range header {
+		content += commentPrefix + " " + h + "\n"
+	}
+	content += text + "\n"
+	_, err = f.WriteString(content)
+	f.Close()
+	if err != nil {
+		return "", fmt.Errorf("error while writing temp file: %w", err)
+	}
+	if err := runEditor(f.Name()); err != nil {
+		return "", err
+	}
+	b, err := os.ReadFile(f.Name())
+	if err != nil {
+		return "", fmt.Errorf("error while reading edited file: %w", err)
+	}
+	return stripHeader(string(b), len(header)), nil
+}
+
+func runEditor(filename string) error {
+	editor := getEditor()
+	log.Printf("Run editor %s on %s", editor, filename)
+	args := strings.Fields(editor)
+	cmd := exec.Command(args[0], append(args[1:], filename)...)
+	// The editor might be run from fzf, where stdin and stdout are not the terminal.
+	if tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0); err == nil {
+		defer tty.Close()
+		cmd.Stdin, cmd.Stdout, cmd.Stderr = tty, tty, tty
+	} else {
+		cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	}
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("error while running editor %s: %w", editor, err)
+	}
+	return nil
+}
+
+func getEditor() string {
+	for _, v := range []string{"VISUAL", "EDITOR"} {
+		if e := strings.TrimSpace(os.Getenv(v)); e != "" {
+			return e
+		}
+	}
+	return defaultEditor
+}
+
+// stripHeader removes the header lines, that is up to the given number of leading comment lines. The text right after
+// the header is kept even if it starts with #, since notes can start with # as well.
+func stripHeader(s string, headerLines int) string {
+	lines := strings.Split(s, "\n")
+	i := 0
+	for i < len(lines) && i < headerLines && strings.HasPrefix(lines[i], commentPrefix) {
+		i++
+	}
+	return strings.TrimSpace(strings.Join(lines[i:], "\n"))
+}
@@ -0,0 +1,100 @@
+package fzf
+
+import (
+	"ffgh/config"
+	"ffgh/gh"
+	"ffgh/theme"
+	"ffgh/util"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const ownAuthor = "me"
+
+// columns renders the optional columns of the list, aligned across all the PRs of the list.
+type columns struct {
+	config       config.Columns
+	login        string
+	now          time.Time
+	authorWidth  int
+	ageWidth     int
+	updatedWidth int
+}
+
+func newColumns(c config.Config, prs []gh.PullRequest, now time.Time) columns {
+	cols := columns{config: c.Columns, login: c.Login, now: now}
+	for _, pr := range prs {
+		cols.authorWidth = max(cols.authorWidth, util.StringWidth(cols.author(pr)))
+		cols.ageWidth = max(cols.ageWidth, len(cols.age(pr)))
+		cols.updatedWidth = max(cols.updatedWidth, len(cols.updated(pr)))
+	}
+	return cols
+}
+
+func (c columns) author(pr gh.PullRequest) string {
+	if c.login != "" && strings.EqualFold(pr.Author.Login, c.login) {
+		return ownAuthor
+	}
+	return pr.Author.Login
+}
+
+func (c columns) age(pr gh.PullRequest) string {
+	return shortDuration(c.now.Sub(pr.CreatedAt), time.Hour)
+}
+
+func (c columns) updated(pr gh.PullRequest) string {
+	return shortDuration(c.now.Sub(pr.UpdatedAt), time.Minute)
+}
+
+var durationUnits = []struct {
+	duration time.Duration
+	suffix   string
+}{
+	{24 * time.Hour, "d"},
+	{time.Hour, "h"},
+	{time.Minute, "m"},
+}
+
+// shortDuration formats the duration in days, hours and minutes down to the unit, skipping the zero parts, like 1d2h.
+// The duration shorter than the unit is shown as <1h or <1m.
+func shortDuration(d, unit time.Duration) string {
+	out := ""
+	suffix := ""
+	for _, u := range durationUnits {
+		if u.duration < unit {
+			break
+		}
+		if n := d / u.duration; n > 0 {
+			out += fmt.Sprintf("%d%s", n, u.suffix)
+			d -= n * u.duration
+		}
+		suffix = u.suffix
+	}
+	if out == "" {
+		return "<1" + suffix
+	}
+	return out
+}
+
+// render returns the enabled columns of the PR. The style is applied to the author, so that it can be skipped for the
+// muted PRs.
+func (c columns) render(pr gh.PullRequest, style func(theme.Role, string) string) string {
+	parts := []string{}
+	if c.config.Author {
+		author := util.PadWidth(c.author(pr), c.authorWidth)
+		if c.author(pr) == ownAuthor {
+			author = style(theme.Own, author)
+		} else if pr.Author.IsBot {
+			author = style(theme.Bot, author)
+		}
+		parts = append(parts, author)
+	}
+	if c.config.Age {
+		parts = append(parts, strings.Repeat(" ", c.ageWidth-len(c.age(pr)))+c.age(pr))
+	}
+	if c.config.Updated {
+		parts = append(parts, strings.Repeat(" ", c.updatedWidth-len(c.updated(pr)))+c.updated(pr))
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,29 @@
+package fzf
+
+import (
+	"testing"
+	"time"
+)
+
+func TestShortDuration(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		unit time.Duration
+		want string
+	}{
+		{0, time.Hour, "<1h"},
+		{29 * time.Minute, time.Hour, "<1h"},
+		{-time.Minute, time.Hour, "<1h"},
+		{3*time.Hour + 40*time.Minute, time.Hour, "3h"},
+		{24 * time.Hour, time.Hour, "1d"},
+		{26*time.Hour + 10*time.Minute, time.Hour, "1d2h"},
+		{10 * time.Second, time.Minute, "<1m"},
+		{time.Hour, time.Minute, "1h"},
+		{25*time.Hour + 5*time.Minute, time.Minute, "1d1h5m"},
+	}
+	for _, tt := range tests {
+		if got := shortDuration(tt.d, tt.unit); got != tt.want {
+			t.Errorf("shortDuration(%s, %s) = %q, want %q", tt.d, tt.unit, got, tt.want)
+		}
+	}
+}
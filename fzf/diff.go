@@ -0,0 +1,86 @@
+package fzf
+
+import (
+	"ffgh/theme"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	PreviewDetails = "details"
+	PreviewDiff    = "diff"
+)
+
+// TogglePreviewMode switches the preview between the PR details and the diff.
+func TogglePreviewMode(mode string) string {
+	if mode == PreviewDiff {
+		return PreviewDetails
+	}
+	return PreviewDiff
+}
+
+type fileStat struct {
+	path    string
+	added   int
+	removed int
+}
+
+// FprintDiff prints the summary of the changed files followed by the diff, with the added and removed lines colored.
+func FprintDiff(out io.Writer, diff string) {
+	lines := strings.Split(strings.TrimRight(diff, "\n"), "\n")
+	stats := []fileStat{}
+	// The file header is skipped until the first hunk, as its "--- " and "+++ " lines are not changes.
+	inHeader := false
+	for _, line := range lines {
+		if path, ok := strings.CutPrefix(line, "diff --git "); ok {
+			// The path is taken from the "b/" side, so that renamed files show their new name.
+			if i := strings.LastIndex(path, " b/"); i >= 0 {
+				path = path[i+3:]
+			}
+			stats = append(stats, fileStat{path: path})
+			inHeader = true
+		} else if strings.HasPrefix(line, "@@") {
+			inHeader = false
+		} else if len(stats) == 0 || inHeader {
+			continue
+		} else if strings.HasPrefix(line, "+") {
+			stats[len(stats)-1].added++
+		} else if strings.HasPrefix(line, "-") {
+			stats[len(stats)-1].removed++
+		}
+	}
+	pathWidth := 0
+	added, removed := 0, 0
+	for _, st := range stats {
+		pathWidth = max(pathWidth, len(st.path))
+		added += st.added
+		removed += st.removed
+	}
+	fmt.Fprintln(out, theme.S(theme.Header, fmt.Sprintf("%d file(s) changed", len(stats)))+" "+
+		theme.S(theme.Added, fmt.Sprintf("+%d", added))+" "+theme.S(theme.Removed, fmt.Sprintf("-%d", removed)))
+	for _, st := range stats {
+		fmt.Fprintf(out, "  %-*s %s %s\n", pathWidth, st.path,
+			theme.S(theme.Added, fmt.Sprintf("+%d", st.added)), theme.S(theme.Removed, fmt.Sprintf("-%d", st.removed)))
+	}
+	fmt.Fprintln(out)
+	inHeader = false
+	for _, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			inHeader = true
+			fmt.Fprintln(out, theme.S(theme.Header, line))
+		case inHeader && !strings.HasPrefix(line, "@@"):
+			fmt.Fprintln(out, theme.S(theme.Header, line))
+		case strings.HasPrefix(line, "@@"):
+			inHeader = false
+			fmt.Fprintln(out, theme.S(theme.Hunk, line))
+		case strings.HasPrefix(line, "+"):
+			fmt.Fprintln(out, theme.S(theme.Added, line))
+		case strings.HasPrefix(line, "-"):
+			fmt.Fprintln(out, theme.S(theme.Removed, line))
+		default:
+			fmt.Fprintln(out, line)
+		}
+	}
+}
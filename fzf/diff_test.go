@@ -0,0 +1,34 @@
+package fzf
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFprintDiffStats(t *testing.T) {
+	diff := `diff --git a/db.sql b/db.sql
+index 1111111..2222222 100644
+--- a/db.sql
++++ b/db.sql
+@@ -1,3 +1,3 @@
+ select 1;
+--- comment
++++ comment
+-select 2;
+diff --git a/new.txt b/new.txt
+new file mode 100644
+--- /dev/null
++++ b/new.txt
+@@ -0,0 +1 @@
++hello
+`
+	var out strings.Builder
+	FprintDiff(&out, diff)
+	summary := strings.Split(out.String(), "\n")[:3]
+	want := []string{"2 file(s) changed +2 -2", "  db.sql  +1 -2", "  new.txt +1 -0"}
+	for i := range want {
+		if summary[i] != want[i] {
+			t.Errorf("summary line %d = %q, want %q", i, summary[i], want[i])
+		}
+	}
+}
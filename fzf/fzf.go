@@ -1,108 +1,129 @@
 package fzf
 
 import (
-	"cmp"
 	"ffgh/config"
 	"ffgh/gh"
 	"ffgh/ghutil"
+	"ffgh/markdown"
 	"ffgh/storage"
+	"ffgh/theme"
+	"ffgh/util"
 	"fmt"
 	"io"
 	"log"
-	"slices"
 	"strings"
 	"time"
-	"unicode/utf8"
-
-	"github.com/fatih/color"
 )
 
 const nbsp = "\u00A0"
 
+// NoUrl is put in place of the URL in the lines that are not PRs, like the group headers, so the commands can skip
+// them.
+const NoUrl = "-"
+
 // FprintPullRequests prints the list of pull requests, one per line, in a format suitable for consumption by `fzf`.
 func FprintPullRequests(out io.Writer, terminalWidth int, prs []gh.PullRequest, userState *storage.UserState, config config.Config) {
 	log.Printf("Use terminal width of %d", terminalWidth)
-	isMute := func(pr gh.PullRequest) bool {
-		return ghutil.IsMute(userState, pr)
-	}
-
-	useMuted := func(prs []gh.PullRequest) []gh.PullRequest {
-		filtered := []gh.PullRequest{}
-		for _, pr := range prs {
-			if isMute(pr) {
-				filtered = append(filtered, pr)
-			}
-		}
-		return filtered
-	}
-
-	useNotMuted := func(prs []gh.PullRequest) []gh.PullRequest {
-		filtered := []gh.PullRequest{}
-		for _, pr := range prs {
-			if !isMute(pr) {
-				filtered = append(filtered, pr)
-			}
-		}
-		return filtered
+	repoNames := repoDisplayNames(prs, config.RepoAliases)
+	isPinned := func(pr gh.PullRequest) bool {
+		return userState.PerUrl[pr.URL].IsPinned
 	}
 
 	displayPriority := make(map[string]int)
 	for i, queryName := range config.DisplayOrder {
 		displayPriority[queryName] = i
 	}
-	slices.SortStableFunc(prs, func(a, b gh.PullRequest) int {
-		return cmp.Compare(a.Number, b.Number)
-	})
+	sortPrs(prs, userState.Settings.SortMode, displayPriority, userState)
 
-	slices.SortStableFunc(prs, func(a, b gh.PullRequest) int {
-		return cmp.Compare(a.Repository.Name, b.Repository.Name)
-	})
+	// Pinned PRs are always shown in their own section at the top, regardless of the view and the tag filter.
+	pinned := filterPrs(prs, isPinned)
+	prs = filterPrs(prs, func(pr gh.PullRequest) bool { return !isPinned(pr) })
 
-	slices.SortStableFunc(prs, func(a, b gh.PullRequest) int {
-		return cmp.Compare(displayPriority[a.Meta.Label], displayPriority[b.Meta.Label])
+	if tag := userState.Settings.TagFilter; tag != "" {
+		prs = filterPrs(prs, func(pr gh.PullRequest) bool { return userState.PerUrl[pr.URL].HasTag(tag) })
+	}
+
+	prs = append(pinned, applyView(FindView(userState.Settings.ViewMode, GetViews(config)), prs, userState)...)
+
+	groupKey := func(pr gh.PullRequest) string { return "" }
+	if config.GroupBy == GroupByQuery {
+		groupKey = func(pr gh.PullRequest) string { return pr.Meta.Label }
+	} else if config.GroupBy == GroupByRepo {
+		groupKey = func(pr gh.PullRequest) string { return pr.Repository.NameWithOwner }
+	} else if config.GroupBy != "" {
+		log.Printf("Unknown grouping %q, not grouping", config.GroupBy)
+	}
+	groups := groupPrs(prs, func(pr gh.PullRequest) string {
+		if isPinned(pr) {
+			return groupPinned
+		}
+		return groupKey(pr)
 	})
+	if config.GroupBy == GroupByQuery {
+		sortGroups(groups, displayPriority)
+	} else {
+		sortGroups(groups, nil)
+	}
 
-	if mode := userState.Settings.ViewMode; mode == ViewModeMuteTop {
-		newPrs := append([]gh.PullRequest{}, useNotMuted(prs)...)
-		prs = append(newPrs, useMuted(prs)...)
-	} else if mode == ViewModeHideMute {
-		prs = useNotMuted(prs)
+	repoNameMaxLen := getMaxRepoLen(prs, repoNames)
+	cols := newColumns(config, prs, time.Now())
+	for _, group := range groups {
+		if config.GroupBy != "" {
+			fmt.Fprintf(out, "%s\t%s\n", NoUrl, theme.S(theme.Header, fmt.Sprintf("── %s (%d)", group.name, len(group.prs))))
+		}
+		fprintPullRequestLines(out, terminalWidth, repoNames, repoNameMaxLen, cols, group.prs, userState, config)
 	}
+}
 
-	repoNameMaxLen := getMaxRepoLen(prs)
+func fprintPullRequestLines(out io.Writer, terminalWidth int, repoNames map[string]string, repoNameMaxLen int, cols columns, prs []gh.PullRequest, userState *storage.UserState, config config.Config) {
+	isMute := func(pr gh.PullRequest) bool {
+		return ghutil.IsMute(userState, pr)
+	}
+	templates := parseLineFormat(config.LineFormat)
 	for _, pr := range prs {
 		prState := userState.PerUrl[pr.URL]
 		flagString := ""
 		flags := storage.GetPrStateFlags(pr, prState)
 		log.Printf("Flags for %s: b%b", pr.URL, flags)
 		mute := isMute(pr)
-		unmutedOnly := func(c func(string, ...any) string, s string) string {
+		unmutedOnly := func(role theme.Role, s string) string {
 			if mute {
 				return s
 			} else {
-				return c(s)
+				return theme.S(role, s)
 			}
 		}
+		if prState.IsPinned {
+			flagString += unmutedOnly(theme.Pinned, "P")
+		} else {
+			flagString += nbsp
+		}
 		if flags&storage.IS_NEW != 0 {
-			flagString += unmutedOnly(color.GreenString, "N")
+			flagString += unmutedOnly(theme.New, "N")
 		} else {
 			flagString += nbsp
 		}
 		if flags&storage.IS_UPDATED != 0 {
-			flagString += unmutedOnly(color.HiWhiteString, "U")
+			flagString += unmutedOnly(theme.Updated, "U")
 		} else {
 			flagString += nbsp
 		}
 		if flags&storage.HAS_NEW_COMMENTS != 0 {
-			flagString += unmutedOnly(color.HiYellowString, "C")
+			flagString += unmutedOnly(theme.Comments, "C")
 		} else {
 			flagString += nbsp
 		}
-		title := pr.Title
 		note := ""
 		if prState.Note != "" {
-			//title = title + unmutedOnly(color.CyanString, " ["+prState.Note+"]")
-			note = unmutedOnly(color.CyanString, " ["+prState.Note+"]")
+			note = unmutedOnly(theme.Note, "["+prState.Note+"]")
+		}
+		tags := ""
+		if len(prState.Tags) > 0 {
+			tags = unmutedOnly(theme.Tags, formatTags(prState.Tags))
+		}
+		newComments := ""
+		if n := storage.NewCommentCount(pr, prState); n > 0 {
+			newComments = unmutedOnly(theme.Comments, formatNewComments(n))
 		}
 
 		shortLabel := " "
@@ -111,24 +132,44 @@ func FprintPullRequests(out io.Writer, terminalWidth int, prs []gh.PullRequest,
 				shortLabel = q.ShortName
 			}
 		}
-		leftParts := []string{
-			flagString,
-			toLeftS(pr.Repository.Name, repoNameMaxLen),
-			shortLabel,
-			fmt.Sprintf("#%-5d", pr.Number),
-			title,
+		labels := []string{}
+		for _, l := range pr.Labels {
+			labels = append(labels, l.Name)
 		}
-		lineLeft := strings.Join(leftParts, " ")
-		lineRight := note
+		owner, _, _ := strings.Cut(pr.Repository.NameWithOwner, "/")
+		lineLeft, lineRight := templates.render(LineFields{
+			URL:         pr.URL,
+			Flags:       flagString,
+			Repo:        repoNames[pr.Repository.NameWithOwner],
+			OwnerRepo:   pr.Repository.NameWithOwner,
+			Owner:       owner,
+			RepoWidth:   repoNameMaxLen,
+			ShortLabel:  shortLabel,
+			Query:       pr.Meta.Label,
+			Number:      pr.Number,
+			Title:       pr.Title,
+			Author:      pr.Author.Login,
+			Age:         cols.age(pr),
+			UpdatedAgo:  cols.updated(pr),
+			Labels:      strings.Join(labels, ","),
+			Comments:    pr.CommentsCount,
+			NewComments: newComments,
+			Note:        note,
+			Tags:        tags,
+			Columns:     cols.render(pr, unmutedOnly),
+		})
 		line := fmt.Sprintf("%s\t%s", pr.URL, joinStringsCapWidth(terminalWidth, lineLeft, lineRight))
 		if mute {
-			line = color.HiBlackString(line)
+			line = theme.S(theme.Muted, line)
 		}
 		fmt.Fprint(out, line+"\n")
 	}
 }
 
-func FprintShowPullRequest(out io.Writer, prUrl string, prs []gh.PullRequest, userPrState *storage.UserState) {
+// FprintShowPullRequest prints the details of the pull request, with the body rendered from Markdown and wrapped to the
+// width, followed by the comments. The comments posted since the PR was last opened are highlighted, except the
+// comments of the user with the login.
+func FprintShowPullRequest(out io.Writer, width int, prUrl string, prs []gh.PullRequest, userPrState *storage.UserState, comments []gh.Comment, login string) {
 	var pr *gh.PullRequest
 	for i := range prs {
 		if prs[i].URL == prUrl {
@@ -143,84 +184,121 @@ func FprintShowPullRequest(out io.Writer, prUrl string, prs []gh.PullRequest, us
 	prState := userPrState.PerUrl[pr.URL]
 	note := ""
 	if prState.Note != "" {
-		note = color.YellowString("[" + prState.Note + "]")
+		note = theme.S(theme.Details, "["+prState.Note+"]")
+	}
+	tags := ""
+	if len(prState.Tags) > 0 {
+		tags = theme.S(theme.Tags, formatTags(prState.Tags))
 	}
 	flags := storage.GetPrStateFlags(*pr, prState)
 	flagString := ""
 
+	if prState.IsPinned {
+		flagString += theme.S(theme.Pinned, "PINNED ")
+	}
 	if flags&storage.IS_NEW != 0 {
-		flagString += color.GreenString("NEW ")
+		flagString += theme.S(theme.New, "NEW ")
 	}
 	if flags&storage.IS_UPDATED != 0 {
-		flagString += color.HiWhiteString("UPDATED ")
+		flagString += theme.S(theme.Updated, "UPDATED ")
 	}
 	if flags&storage.HAS_NEW_COMMENTS != 0 {
-		flagString += color.HiYellowString("COMMENTS")
+		flagString += theme.S(theme.Comments, "COMMENTS")
 	}
 
 	now := time.Now()
 	details := []string{
-		color.HiRedString(pr.Repository.NameWithOwner),
-		color.CyanString(
+		theme.S(theme.Repo, pr.Repository.NameWithOwner),
+		theme.S(theme.Title,
 			fmt.Sprintf("(#%d) %s", pr.Number, pr.Title),
 		),
 		"",
 		flagString,
-		color.YellowString(fmt.Sprintf("%s (%s)", pr.Author.Login, pr.Meta.Label)),
-		color.YellowString(fmt.Sprintf("Created %s, updated %s ago",
+		theme.S(theme.Author, fmt.Sprintf("%s (%s)", pr.Author.Login, pr.Meta.Label)),
+		theme.S(theme.Details, fmt.Sprintf("Created %s, updated %s ago",
 			PrettyDuration(now.Sub(pr.CreatedAt).Round(time.Minute)),
 			PrettyDuration(now.Sub(pr.UpdatedAt).Round(time.Minute)),
 		)),
-		color.YellowString(fmt.Sprintf("%d comment(s)", pr.CommentsCount)),
+		theme.S(theme.Details, fmt.Sprintf("%d comment(s)", pr.CommentsCount)),
+		theme.S(theme.Comments, formatNewComments(storage.NewCommentCount(*pr, prState))),
 		note,
-		"",
-		pr.Body,
+		tags,
+	}
+	if len(prState.NoteHistory) > 0 {
+		details = append(details, "", theme.S(theme.Details, "Notes:"))
+		for _, n := range prState.NoteHistory {
+			details = append(details, fmt.Sprintf("%s %s",
+				theme.S(theme.Timestamp, n.CreatedAt.Local().Format(time.DateTime)),
+				n.Text,
+			))
+		}
+	}
+	details = append(details, "", markdown.Render(pr.Body, width))
+	if len(comments) > 0 {
+		details = append(details, "", theme.S(theme.Header, fmt.Sprintf("── Comments (%d)", len(comments))))
+	}
+	for _, c := range comments {
+		header := theme.S(theme.Author, c.Author.Login)
+		if c.Author.IsBot {
+			header = theme.S(theme.Bot, c.Author.Login)
+		}
+		header += " " + theme.S(theme.Timestamp, c.CreatedAt.Local().Format(time.DateTime))
+		if c.Path != "" {
+			header += " " + theme.S(theme.Details, c.Path)
+		}
+		isOwn := login != "" && strings.EqualFold(c.Author.Login, login)
+		if !isOwn && (prState.OpenedAt == nil || c.CreatedAt.After(*prState.OpenedAt)) {
+			header = theme.S(theme.New, "NEW") + " " + header
+		}
+		details = append(details, "", header)
+		for _, line := range strings.Split(markdown.Render(c.Body, width-2), "\n") {
+			details = append(details, "  "+line)
+		}
 	}
 	fmt.Fprint(out, strings.Join(details, "\n"))
 }
 
-func getMaxRepoLen(prs []gh.PullRequest) int {
-	repoNameMaxLen := 0
-	for _, pr := range prs {
-		if l := len(pr.Repository.Name); l > repoNameMaxLen {
-			repoNameMaxLen = l
-		}
+func formatNewComments(n int) string {
+	if n == 0 {
+		return ""
+	}
+	if n == 1 {
+		return "+1 comment"
 	}
-	return repoNameMaxLen
+	return fmt.Sprintf("+%d comments", n)
 }
 
-func toLeftS(s string, w int) string {
-	f := fmt.Sprintf("%%-%ds", w) // like %-10s
-	return fmt.Sprintf(f, s)
+func formatTags(tags []string) string {
+	formatted := []string{}
+	for _, t := range tags {
+		formatted = append(formatted, "#"+t)
+	}
+	return strings.Join(formatted, " ")
+}
+
+func filterPrs(prs []gh.PullRequest, keep func(gh.PullRequest) bool) []gh.PullRequest {
+	filtered := []gh.PullRequest{}
+	for _, pr := range prs {
+		if keep(pr) {
+			filtered = append(filtered, pr)
+		}
+	}
+	return filtered
 }
 
-const elypsis = '…'
+const elypsis = "…"
 
+// joinStringsCapWidth joins the strings so they fit the width in terminal cells. If they don't fit, the left string is
+// cut and ends with an ellipsis, so the right string is always shown whole, unless it's wider than the whole width.
 func joinStringsCapWidth(width int, left, right string) string {
-	leftSize := utf8.RuneCountInString(left)
-	rightSize := utf8.RuneCountInString(right)
+	leftSize := util.StringWidth(left)
+	rightSize := util.StringWidth(right)
 	if leftSize+rightSize <= width {
 		return left + right
 	}
-	buf := make([]rune, width)
-	i := 0
-	for _, r := range left {
-		if i >= len(buf) {
-			break
-		}
-		buf[i] = r
-		i++
-	}
-	i = len(buf) - rightSize
-	if j := (i - 1); j >= 0 {
-		buf[j] = elypsis
-	}
-	for _, r := range right {
-		if i >= len(buf) {
-			break
-		}
-		buf[i] = r
-		i++
+	if rightSize >= width {
+		return util.TruncateWidth(right, width)
 	}
-	return string(buf)
+	leftWidth := width - rightSize - 1
+	return util.PadWidth(util.TruncateWidth(left, leftWidth), leftWidth) + elypsis + right
 }
@@ -0,0 +1,33 @@
+package fzf
+
+import (
+	"ffgh/util"
+	"testing"
+)
+
+func TestJoinStringsCapWidth(t *testing.T) {
+	tests := []struct {
+		name        string
+		width       int
+		left, right string
+		want        string
+	}{
+		{"fits", 10, "ab", "cd", "abcd"},
+		{"left cut", 6, "abcdef", "xy", "abc…xy"},
+		{"colored right", 10, "0123456789", "\x1b[36m[note]\x1b[0m", "012…\x1b[36m[note]\x1b[0m"},
+		{"colored right wider than width", 4, "abc", "\x1b[36m[long note]\x1b[0m", "\x1b[36m[lon\x1b[0m"},
+		{"left cut inside wide character", 6, "日本語", "ab", "日 …ab"},
+		{"colored left", 6, "\x1b[31mabcdef\x1b[0m", "xy", "\x1b[31mabc\x1b[0m…xy"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := joinStringsCapWidth(tt.width, tt.left, tt.right)
+			if got != tt.want {
+				t.Errorf("joinStringsCapWidth(%d, %q, %q) = %q, want %q", tt.width, tt.left, tt.right, got, tt.want)
+			}
+			if w := util.StringWidth(got); w > tt.width {
+				t.Errorf("joinStringsCapWidth(%d, %q, %q) is %d cells wide", tt.width, tt.left, tt.right, w)
+			}
+		})
+	}
+}
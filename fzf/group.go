@@ -0,0 +1,51 @@
+package fzf
+
+import (
+	"cmp"
+	"ffgh/gh"
+	"ffgh/util"
+	"slices"
+)
+
+const (
+	GroupByQuery = "query"
+	GroupByRepo  = "repo"
+)
+
+const groupPinned = "Pinned"
+
+type prGroup struct {
+	name string
+	prs  []gh.PullRequest
+}
+
+// groupPrs splits the PRs into groups by the key, keeping the order of the PRs within the groups.
+func groupPrs(prs []gh.PullRequest, key func(gh.PullRequest) string) []prGroup {
+	groups := []prGroup{}
+	index := make(map[string]int)
+	for _, pr := range prs {
+		k := key(pr)
+		i, ok := index[k]
+		if !ok {
+			i = len(groups)
+			index[k] = i
+			groups = append(groups, prGroup{name: k})
+		}
+		groups[i].prs = append(groups[i].prs, pr)
+	}
+	return groups
+}
+
+// sortGroups puts the pinned group first, and the other groups in the order of priority, or by name if the priority
+// is not given.
+func sortGroups(groups []prGroup, priority map[string]int) {
+	slices.SortStableFunc(groups, func(a, b prGroup) int {
+		if c := cmp.Compare(util.BoolToInt(b.name == groupPinned), util.BoolToInt(a.name == groupPinned)); c != 0 {
+			return c
+		}
+		if priority != nil {
+			return cmp.Compare(priority[a.name], priority[b.name])
+		}
+		return cmp.Compare(a.name, b.name)
+	})
+}
@@ -0,0 +1,98 @@
+package fzf
+
+import (
+	"ffgh/config"
+	"ffgh/util"
+	"fmt"
+	"log"
+	"strings"
+	"text/template"
+)
+
+// DefaultLineFormat is the layout of the list line used when the config does not set one.
+var DefaultLineFormat = config.LineFormat{
+	Left:  "{{.Flags}} {{.Repo | left .RepoWidth}} {{.ShortLabel}} #{{.Number | left 5}} {{.Title}}",
+	Right: "{{with .NewComments}} {{.}}{{end}}{{with .Note}} {{.}}{{end}}{{with .Tags}} {{.}}{{end}}{{with .Columns}} {{.}}{{end}}",
+}
+
+// LineFields are the fields available in the line format template. Some of the fields are already colored. Repo is the
+// alias of the repository, or its name with the owner only if another repository has the same name. Columns are the
+// optional columns enabled in the config. NewComments is the number of comments since the PR was last opened, like "+2
+// comments", or empty if there are none.
+type LineFields struct {
+	URL         string
+	Flags       string
+	Repo        string
+	OwnerRepo   string
+	Owner       string
+	RepoWidth   int
+	ShortLabel  string
+	Query       string
+	Number      int
+	Title       string
+	Author      string
+	Age         string
+	UpdatedAgo  string
+	Labels      string
+	Comments    int
+	NewComments string
+	Note        string
+	Tags        string
+	Columns     string
+}
+
+var lineFormatFuncs = template.FuncMap{
+	// left pads the value with spaces on the right to the width.
+	"left": func(width int, v any) string {
+		s := fmt.Sprint(v)
+		return util.PadWidth(s, width)
+	},
+	// right pads the value with spaces on the left to the width.
+	"right": func(width int, v any) string {
+		s := fmt.Sprint(v)
+		return strings.Repeat(" ", max(0, width-util.StringWidth(s))) + s
+	},
+	// trunc cuts the value to the width, ending with an ellipsis.
+	"trunc": func(width int, v any) string {
+		s := fmt.Sprint(v)
+		if util.StringWidth(s) <= width {
+			return s
+		}
+		return util.TruncateWidth(s, max(0, width-1)) + elypsis
+	},
+}
+
+type lineTemplates struct {
+	left  *template.Template
+	right *template.Template
+}
+
+// parseLineFormat parses the configured line format, falling back to the default one for the parts that are not
+// set or are not valid.
+func parseLineFormat(f config.LineFormat) lineTemplates {
+	parse := func(name, text, fallback string) *template.Template {
+		if text != "" {
+			t, err := template.New(name).Funcs(lineFormatFuncs).Parse(text)
+			if err == nil {
+				return t
+			}
+			log.Printf("Bad line format %q, use the default: %s", text, err)
+		}
+		return template.Must(template.New(name).Funcs(lineFormatFuncs).Parse(fallback))
+	}
+	return lineTemplates{
+		left:  parse("left", f.Left, DefaultLineFormat.Left),
+		right: parse("right", f.Right, DefaultLineFormat.Right),
+	}
+}
+
+func (t lineTemplates) render(fields LineFields) (string, string) {
+	execute := func(tmpl *template.Template) string {
+		var b strings.Builder
+		if err := tmpl.Execute(&b, fields); err != nil {
+			log.Printf("Error while formatting line of %s: %s", fields.URL, err)
+		}
+		return b.String()
+	}
+	return execute(t.left), execute(t.right)
+}
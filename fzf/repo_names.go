@@ -0,0 +1,44 @@
+package fzf
+
+import (
+	"ffgh/gh"
+	"ffgh/util"
+)
+
+// repoDisplayNames returns the names of the repositories shown in the list, by the name with owner. A repository is
+// shown by its alias if it has one, by its name if no other repository has the same name, and by its name with owner
+// otherwise. The collisions are checked on all the PRs, so that the names don't change when switching views.
+func repoDisplayNames(prs []gh.PullRequest, aliases map[string]string) map[string]string {
+	owners := make(map[string]map[string]bool)
+	for _, pr := range prs {
+		if owners[pr.Repository.Name] == nil {
+			owners[pr.Repository.Name] = make(map[string]bool)
+		}
+		owners[pr.Repository.Name][pr.Repository.NameWithOwner] = true
+	}
+	names := make(map[string]string)
+	for _, pr := range prs {
+		repo := pr.Repository
+		if alias, ok := aliases[repo.NameWithOwner]; ok {
+			names[repo.NameWithOwner] = alias
+		} else if alias, ok := aliases[repo.Name]; ok && len(owners[repo.Name]) == 1 {
+			names[repo.NameWithOwner] = alias
+		} else if len(owners[repo.Name]) > 1 {
+			names[repo.NameWithOwner] = repo.NameWithOwner
+		} else {
+			names[repo.NameWithOwner] = repo.Name
+		}
+	}
+	return names
+}
+
+// getMaxRepoLen returns the width of the widest repository name shown for the PRs.
+func getMaxRepoLen(prs []gh.PullRequest, names map[string]string) int {
+	repoNameMaxLen := 0
+	for _, pr := range prs {
+		if l := util.StringWidth(names[pr.Repository.NameWithOwner]); l > repoNameMaxLen {
+			repoNameMaxLen = l
+		}
+	}
+	return repoNameMaxLen
+}
@@ -0,0 +1,95 @@
+package fzf
+
+import (
+	"cmp"
+	"ffgh/gh"
+	"ffgh/storage"
+	"ffgh/util"
+	"slices"
+)
+
+const (
+	SortModeQuery     = "query"
+	SortModeUpdated   = "updated"
+	SortModeCreated   = "created"
+	SortModeRequested = "requested"
+	SortModeComments  = "comments"
+	SortModeRepo      = "repo"
+)
+
+var sortModes = []string{
+	SortModeQuery,
+	SortModeUpdated,
+	SortModeCreated,
+	SortModeRequested,
+	SortModeComments,
+	SortModeRepo,
+}
+
+// SortModeDescriptions are shown in help.
+var SortModeDescriptions = map[string]string{
+	SortModeQuery:     "By display order of the queries, then by repository and number",
+	SortModeUpdated:   "Recently updated first",
+	SortModeCreated:   "Recently created first",
+	SortModeRequested: "Oldest review request first, then the PRs without review requests",
+	SortModeComments:  "Most new comments first, then most comments",
+	SortModeRepo:      "By repository and number",
+}
+
+func CycleSortMode(m string) string {
+	return util.Cycle(m, sortModes)
+}
+
+// SortModes returns the sort modes in the cycle order.
+func SortModes() []string {
+	return slices.Clone(sortModes)
+}
+
+// sortPrs sorts the PRs in place. The default order, by display priority, repository and number, breaks the ties of
+// the other sort modes.
+func sortPrs(prs []gh.PullRequest, mode string, displayPriority map[string]int, userState *storage.UserState) {
+	slices.SortStableFunc(prs, func(a, b gh.PullRequest) int {
+		return cmp.Compare(a.Number, b.Number)
+	})
+
+	slices.SortStableFunc(prs, func(a, b gh.PullRequest) int {
+		return cmp.Compare(a.Repository.Name, b.Repository.Name)
+	})
+
+	if mode == SortModeRepo {
+		return
+	}
+
+	slices.SortStableFunc(prs, func(a, b gh.PullRequest) int {
+		return cmp.Compare(displayPriority[a.Meta.Label], displayPriority[b.Meta.Label])
+	})
+
+	switch mode {
+	case SortModeUpdated:
+		slices.SortStableFunc(prs, func(a, b gh.PullRequest) int {
+			return b.UpdatedAt.Compare(a.UpdatedAt)
+		})
+	case SortModeCreated:
+		slices.SortStableFunc(prs, func(a, b gh.PullRequest) int {
+			return b.CreatedAt.Compare(a.CreatedAt)
+		})
+	case SortModeRequested:
+		slices.SortStableFunc(prs, func(a, b gh.PullRequest) int {
+			ra, rb := a.Meta.ReviewRequestedAt, b.Meta.ReviewRequestedAt
+			if ra == nil || rb == nil {
+				return cmp.Compare(util.BoolToInt(ra == nil), util.BoolToInt(rb == nil))
+			}
+			return ra.Compare(*rb)
+		})
+	case SortModeComments:
+		newComments := func(pr gh.PullRequest) int {
+			return storage.NewCommentCount(pr, userState.PerUrl[pr.URL])
+		}
+		slices.SortStableFunc(prs, func(a, b gh.PullRequest) int {
+			if c := cmp.Compare(newComments(b), newComments(a)); c != 0 {
+				return c
+			}
+			return cmp.Compare(b.CommentsCount, a.CommentsCount)
+		})
+	}
+}
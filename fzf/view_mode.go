@@ -1,6 +1,14 @@
 package fzf
 
-import "ffgh/util"
+import (
+	"ffgh/config"
+	"ffgh/gh"
+	"ffgh/ghutil"
+	"ffgh/storage"
+	"ffgh/util"
+	"log"
+	"slices"
+)
 
 const (
 	ViewModeRegular  = "regular"
@@ -8,12 +16,95 @@ const (
 	ViewModeHideMute = "hide-mute"
 )
 
-var viewModes = []string{
-	ViewModeRegular,
-	ViewModeMuteTop,
-	ViewModeHideMute,
+var builtinViews = []config.View{
+	{Name: ViewModeRegular, Description: "Show all PRs", Mute: config.MuteShow},
+	{Name: ViewModeMuteTop, Description: "Show the muted PRs after the others", Mute: config.MuteLast},
+	{Name: ViewModeHideMute, Description: "Hide the muted PRs", Mute: config.MuteHide},
+}
+
+// GetViews returns the views defined in config, or the built-in views if there are none.
+func GetViews(c config.Config) []config.View {
+	if len(c.Views) > 0 {
+		return c.Views
+	}
+	return builtinViews
+}
+
+func CycleViewMode(m string, views []config.View) string {
+	names := []string{}
+	for _, v := range views {
+		names = append(names, v.Name)
+	}
+	return util.Cycle(m, names)
+}
+
+// FindView returns the view with the name, or the first view if there is no such view.
+func FindView(name string, views []config.View) config.View {
+	for _, v := range views {
+		if v.Name == name {
+			return v
+		}
+	}
+	if len(views) == 0 {
+		return config.View{}
+	}
+	return views[0]
+}
+
+// applyView filters and orders the PRs according to the view. It is applied to the PRs that are not pinned, as the
+// pinned PRs are always shown.
+func applyView(view config.View, prs []gh.PullRequest, userState *storage.UserState) []gh.PullRequest {
+	prs = filterPrs(prs, func(pr gh.PullRequest) bool {
+		return matchesView(view, pr, userState.PerUrl[pr.URL])
+	})
+	isMute := func(pr gh.PullRequest) bool {
+		return ghutil.IsMute(userState, pr)
+	}
+	switch view.Mute {
+	case config.MuteLast:
+		notMuted := filterPrs(prs, func(pr gh.PullRequest) bool { return !isMute(pr) })
+		prs = append(notMuted, filterPrs(prs, isMute)...)
+	case config.MuteHide:
+		prs = filterPrs(prs, func(pr gh.PullRequest) bool { return !isMute(pr) })
+	case config.MuteOnly:
+		prs = filterPrs(prs, isMute)
+	case config.MuteShow, "":
+	default:
+		log.Printf("Unknown mute handling %q in view %s", view.Mute, view.Name)
+	}
+	return prs
 }
 
-func CycleViewMode(m string) string {
-	return util.Cycle(m, viewModes)
+func matchesView(view config.View, pr gh.PullRequest, prState storage.PrState) bool {
+	if len(view.Queries) > 0 && !slices.Contains(view.Queries, pr.Meta.Label) {
+		return false
+	}
+	if len(view.Repos) > 0 &&
+		!slices.Contains(view.Repos, pr.Repository.Name) &&
+		!slices.Contains(view.Repos, pr.Repository.NameWithOwner) {
+		return false
+	}
+	if len(view.Tags) > 0 && !slices.ContainsFunc(view.Tags, prState.HasTag) {
+		return false
+	}
+	if len(view.Flags) > 0 {
+		flags := storage.GetPrStateFlags(pr, prState)
+		matched := false
+		for _, f := range view.Flags {
+			switch f {
+			case config.FlagNew:
+				matched = matched || flags&storage.IS_NEW != 0
+			case config.FlagUpdated:
+				matched = matched || flags&storage.IS_UPDATED != 0
+			case config.FlagComments:
+				matched = matched || flags&storage.HAS_NEW_COMMENTS != 0
+			default:
+				log.Printf("Unknown flag %q in view %s", f, view.Name)
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
 }
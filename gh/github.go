@@ -15,12 +15,17 @@ type Repository struct {
 	NameWithOwner string `json:"nameWithOwner"`
 }
 
+type Label struct {
+	Name string `json:"name"`
+}
+
 type PullRequest struct {
 	Author        Author     `json:"author"`
 	Body          string     `json:"body"`
 	CommentsCount int        `json:"commentsCount"`
 	CreatedAt     time.Time  `json:"createdAt"`
 	ID            string     `json:"id"`
+	Labels        []Label    `json:"labels"`
 	Number        int        `json:"number"`
 	Repository    Repository `json:"repository"`
 	Title         string     `json:"title"`
@@ -34,4 +39,25 @@ type PullRequest struct {
 type Meta struct {
 	Label       string
 	DefaultMute bool
+	// OwnCommentTimes are the times of the user's own conversation comments. They are known only for the PRs with new
+	// comments.
+	OwnCommentTimes []time.Time `json:",omitempty"`
+	// LastActivityBy is the login of the author of the last activity of the PR, like a comment, a review or a push,
+	// and IsOwnUpdate says it was the user. They are known only for the updated PRs.
+	LastActivityBy string `json:",omitempty"`
+	IsOwnUpdate    bool   `json:",omitempty"`
+	// ReviewRequestedAt is when the user's review was last requested, directly or from a team, or nil if it was not.
+	// ReviewRequestsChecked says that the review requests were fetched.
+	ReviewRequestedAt     *time.Time `json:",omitempty"`
+	ReviewRequestsChecked bool       `json:",omitempty"`
+}
+
+// Comment is a comment of a PR, either a conversation comment or a review comment on a line of a file.
+type Comment struct {
+	Author    Author    `json:"author"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"createdAt"`
+	URL       string    `json:"url"`
+	// Path is the file of the review comment, empty for the conversation comments.
+	Path string `json:"path,omitempty"`
 }
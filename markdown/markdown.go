@@ -0,0 +1,221 @@
+package markdown
+
+import (
+	"ffgh/theme"
+	"ffgh/util"
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+)
+
+const minWidth = 20
+
+var (
+	htmlComment = regexp.MustCompile(`(?s)<!--.*?-->`)
+	heading     = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*$`)
+	listItem    = regexp.MustCompile(`^(\s*)([-*+]|\d+[.)])\s+(.*)$`)
+	checkbox    = regexp.MustCompile(`^\[([ xX])\]\s+`)
+	rule        = regexp.MustCompile(`^([-*_])(\s*([-*_]))*$`)
+	tableRule   = regexp.MustCompile(`^\|?[\s:|-]*-[\s:|-]*\|?$`)
+	codeSpan    = regexp.MustCompile("`([^`]+)`")
+	image       = regexp.MustCompile(`!\[([^\]]*)\]\(([^)\s]+)[^)]*\)`)
+	link        = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)[^)]*\)`)
+	autolink    = regexp.MustCompile(`<(https?://[^>\s]+)>`)
+	bold        = regexp.MustCompile(`\*\*([^*]+)\*\*|__([^_]+)__`)
+	htmlTag     = regexp.MustCompile(`</?[a-zA-Z][a-zA-Z0-9-]*(\s[^>]*)?/?>`)
+)
+
+type renderer struct {
+	width int
+	out   []string
+	// links are the URLs of the links, listed at the end so that the long URLs don't break the text.
+	links []string
+}
+
+// Render returns the Markdown text of the PR body styled for the terminal and wrapped to the width. It supports only the
+// common subset of GitHub Markdown: headings, lists with checkboxes, quotes, code blocks and spans, links, bold text and
+// rules. The HTML comments, that the PR templates are full of, are removed, and the other HTML tags are replaced with
+// spaces keeping their text.
+func Render(text string, width int) string {
+	r := &renderer{width: max(width, minWidth)}
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = htmlComment.ReplaceAllString(text, "")
+	lines := strings.Split(text, "\n")
+	paragraph := []string{}
+	flush := func() {
+		if len(paragraph) > 0 {
+			r.wrap(r.inline(strings.Join(paragraph, " ")), "", "")
+			paragraph = nil
+		}
+	}
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimRight(lines[i], " \t")
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			flush()
+			fence := trimmed[:3]
+			for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), fence); i++ {
+				code := strings.ReplaceAll(strings.TrimRight(lines[i], "\r"), "\t", "    ")
+				r.out = append(r.out, "  "+theme.S(theme.Code, code))
+			}
+			r.blank()
+			continue
+		}
+		if trimmed == "" {
+			flush()
+			r.blank()
+			continue
+		}
+		if m := heading.FindStringSubmatch(trimmed); m != nil {
+			flush()
+			r.blank()
+			r.wrap(r.styleWords(theme.Heading, r.plain(m[2])), "", "")
+			r.blank()
+			continue
+		}
+		if rule.MatchString(trimmed) && strings.Count(strings.ReplaceAll(trimmed, " ", ""), trimmed[:1]) >= 3 {
+			flush()
+			r.out = append(r.out, theme.S(theme.Muted, strings.Repeat("─", r.width)))
+			continue
+		}
+		if m := listItem.FindStringSubmatch(line); m != nil {
+			flush()
+			item := m[3]
+			// The indented lines that follow are the continuation of the item.
+			for i+1 < len(lines) && strings.HasPrefix(lines[i+1], "  ") && strings.TrimSpace(lines[i+1]) != "" &&
+				!listItem.MatchString(lines[i+1]) {
+				i++
+				item += " " + strings.TrimSpace(lines[i])
+			}
+			marker := "•"
+			if m[2][0] >= '0' && m[2][0] <= '9' {
+				marker = m[2]
+			}
+			if c := checkbox.FindStringSubmatch(item); c != nil {
+				marker = "☐"
+				if c[1] != " " {
+					marker = "☑"
+				}
+				item = item[len(c[0]):]
+			}
+			indent := strings.Repeat(" ", len(strings.ReplaceAll(m[1], "\t", "    ")))
+			r.wrap(r.inline(item), indent+marker+" ", indent+strings.Repeat(" ", util.StringWidth(marker)+1))
+			continue
+		}
+		if strings.HasPrefix(trimmed, ">") {
+			flush()
+			quote := []string{}
+			for ; i < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i]), ">"); i++ {
+				quote = append(quote, strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(lines[i]), ">")))
+			}
+			i--
+			bar := theme.S(theme.Muted, "│") + " "
+			r.wrap(r.inline(strings.Join(quote, " ")), bar, bar)
+			continue
+		}
+		if strings.HasPrefix(trimmed, "|") {
+			flush()
+			// The tables are kept as they are, as they can't be wrapped.
+			if !tableRule.MatchString(trimmed) {
+				r.out = append(r.out, r.inline(trimmed))
+			}
+			continue
+		}
+		paragraph = append(paragraph, trimmed)
+	}
+	flush()
+	if len(r.links) > 0 {
+		r.blank()
+		for i, url := range r.links {
+			r.out = append(r.out, fmt.Sprintf("%s %s", theme.S(theme.Muted, fmt.Sprintf("[%d]", i+1)), theme.S(theme.Link, url)))
+		}
+	}
+	for len(r.out) > 0 && r.out[len(r.out)-1] == "" {
+		r.out = r.out[:len(r.out)-1]
+	}
+	return strings.Join(r.out, "\n")
+}
+
+// blank adds an empty line, unless there is one already or it would be the first line.
+func (r *renderer) blank() {
+	if len(r.out) > 0 && r.out[len(r.out)-1] != "" {
+		r.out = append(r.out, "")
+	}
+}
+
+// wrap adds the text wrapped to the width, with the first line starting with the first prefix and the others with the
+// rest prefix. The words longer than the width are not broken.
+func (r *renderer) wrap(text, first, rest string) {
+	line := first
+	lineWidth := util.StringWidth(first)
+	empty := true
+	for _, word := range strings.Fields(text) {
+		w := util.StringWidth(word)
+		if !empty && lineWidth+1+w > r.width {
+			r.out = append(r.out, line)
+			line = rest
+			lineWidth = util.StringWidth(rest)
+			empty = true
+		}
+		if !empty {
+			line += " "
+			lineWidth++
+		}
+		line += word
+		lineWidth += w
+		empty = false
+	}
+	r.out = append(r.out, line)
+}
+
+// inline renders the inline elements of the text. The code spans are styled and kept as they are.
+func (r *renderer) inline(text string) string {
+	var b strings.Builder
+	last := 0
+	for _, m := range codeSpan.FindAllStringSubmatchIndex(text, -1) {
+		b.WriteString(r.inlineText(text[last:m[0]]))
+		b.WriteString(r.styleWords(theme.Code, text[m[2]:m[3]]))
+		last = m[1]
+	}
+	b.WriteString(r.inlineText(text[last:]))
+	return b.String()
+}
+
+func (r *renderer) inlineText(text string) string {
+	text = autolink.ReplaceAllString(text, "$1")
+	text = image.ReplaceAllString(text, "[image: $1]")
+	text = link.ReplaceAllStringFunc(text, func(s string) string {
+		m := link.FindStringSubmatch(s)
+		if m[1] == m[2] {
+			return theme.S(theme.Link, m[2])
+		}
+		r.links = append(r.links, m[2])
+		return r.styleWords(theme.Link, m[1]) + theme.S(theme.Muted, fmt.Sprintf("[%d]", len(r.links)))
+	})
+	text = bold.ReplaceAllStringFunc(text, func(s string) string {
+		return r.styleWords(theme.Heading, strings.Trim(s, "*_"))
+	})
+	text = htmlTag.ReplaceAllString(text, " ")
+	return html.UnescapeString(text)
+}
+
+// plain returns the text of the inline elements without styling them, for the text that is styled as a whole.
+func (r *renderer) plain(text string) string {
+	text = codeSpan.ReplaceAllString(text, "$1")
+	text = autolink.ReplaceAllString(text, "$1")
+	text = image.ReplaceAllString(text, "$1")
+	text = link.ReplaceAllString(text, "$1")
+	text = bold.ReplaceAllStringFunc(text, func(s string) string { return strings.Trim(s, "*_") })
+	text = htmlTag.ReplaceAllString(text, " ")
+	return html.UnescapeString(text)
+}
+
+// styleWords styles each word separately, so that the style is not broken by wrapping.
+func (r *renderer) styleWords(role theme.Role, text string) string {
+	words := strings.Fields(text)
+	for i, w := range words {
+		words[i] = theme.S(role, w)
+	}
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,117 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"time"
+)
+
+const stateBundleVersion = 1
+
+// StateBundle is a portable form of the user state, used to carry read marks and notes between machines.
+type StateBundle struct {
+	Version    int
+	ExportedAt time.Time
+	UserState  *UserState
+}
+
+func FprintStateBundle(out io.Writer, state *UserState) error {
+	bundle := StateBundle{
+		Version:    stateBundleVersion,
+		ExportedAt: time.Now(),
+		UserState:  state,
+	}
+	marshalled, err := json.MarshalIndent(bundle, "", " ")
+	if err != nil {
+		return fmt.Errorf("error while marshalling state bundle: %w", err)
+	}
+	_, err = out.Write(append(marshalled, '\n'))
+	return err
+}
+
+func ReadStateBundle(in io.Reader) (*StateBundle, error) {
+	var bundle StateBundle
+	decoder := json.NewDecoder(in)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&bundle); err != nil {
+		return nil, fmt.Errorf("error while unmarshalling state bundle: %w", err)
+	}
+	if bundle.Version != stateBundleVersion {
+		return nil, fmt.Errorf("unsupported state bundle version %d", bundle.Version)
+	}
+	if bundle.UserState == nil || bundle.UserState.PerUrl == nil {
+		return nil, fmt.Errorf("state bundle has no user state")
+	}
+	return &bundle, nil
+}
+
+// Merge merges the other state into this state. The PR state with the newest OpenedAt wins, together with the
+// comment count. The mute and pin states, notes and tags are resolved independently, the most recently updated wins,
+// and the note histories are joined. The mute and pin states saved without their update times follow the read mark.
+// The settings are not merged, they are local to the machine.
+func (s *UserState) Merge(other *UserState) {
+	for url, theirs := range other.PerUrl {
+		ours, ok := s.PerUrl[url]
+		if !ok {
+			s.PerUrl[url] = theirs
+			continue
+		}
+		merged := ours
+		if isAfter(theirs.OpenedAt, ours.OpenedAt) {
+			merged.OpenedAt = theirs.OpenedAt
+			merged.LastCommentCount = theirs.LastCommentCount
+			merged.PreviousOpenedAt = theirs.PreviousOpenedAt
+			merged.PreviousCommentCount = theirs.PreviousCommentCount
+		}
+		if isAfter(theirs.MuteUpdatedAt, ours.MuteUpdatedAt) ||
+			theirs.MuteUpdatedAt == nil && ours.MuteUpdatedAt == nil && isAfter(theirs.OpenedAt, ours.OpenedAt) {
+			merged.IsMute = theirs.IsMute
+			merged.MuteUpdatedAt = theirs.MuteUpdatedAt
+		}
+		if isAfter(theirs.PinUpdatedAt, ours.PinUpdatedAt) ||
+			theirs.PinUpdatedAt == nil && ours.PinUpdatedAt == nil && isAfter(theirs.OpenedAt, ours.OpenedAt) {
+			merged.IsPinned = theirs.IsPinned
+			merged.PinUpdatedAt = theirs.PinUpdatedAt
+		}
+		if isAfter(theirs.NoteUpdatedAt, ours.NoteUpdatedAt) {
+			merged.Note = theirs.Note
+			merged.NoteUpdatedAt = theirs.NoteUpdatedAt
+		}
+		if isAfter(theirs.TagsUpdatedAt, ours.TagsUpdatedAt) {
+			merged.Tags = theirs.Tags
+			merged.TagsUpdatedAt = theirs.TagsUpdatedAt
+		}
+		merged.NoteHistory = mergeNoteHistory(ours.NoteHistory, theirs.NoteHistory)
+		s.PerUrl[url] = merged
+	}
+}
+
+func mergeNoteHistory(ours, theirs []TimedNote) []TimedNote {
+	merged := append([]TimedNote{}, ours...)
+	for _, note := range theirs {
+		// The times are compared with Equal, as the same time decoded from JSON has a different location per decoding.
+		if !slices.ContainsFunc(merged, func(n TimedNote) bool { return n.Text == note.Text && n.CreatedAt.Equal(note.CreatedAt) }) {
+			merged = append(merged, note)
+		}
+	}
+	slices.SortStableFunc(merged, func(a, b TimedNote) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+	if len(merged) == 0 {
+		return nil
+	}
+	return merged
+}
+
+// isAfter returns true if a is set and is later than b. An unset time is the oldest time.
+func isAfter(a, b *time.Time) bool {
+	if a == nil {
+		return false
+	}
+	if b == nil {
+		return true
+	}
+	return a.After(*b)
+}
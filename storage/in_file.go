@@ -1,29 +1,50 @@
 package storage
 
 import (
+	"crypto/sha256"
 	"encoding/json"
+	"errors"
 	"ffgh/gh"
 	"fmt"
 	"log"
 	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 	"time"
 )
 
 const (
 	defaultGitHubState = "gh_daemon_state.json"
 	defaultUserState   = "gh_user_state.json"
+	defaultJournal     = "gh_user_journal.jsonl"
+	defaultComments    = "gh_comments"
+	defaultLogin       = "gh_login"
+	defaultDiffs       = "gh_diffs"
 )
 
 func NewFileStorage() *FileStorage {
 	return &FileStorage{
 		PrsStatePath:  defaultGitHubState,
 		UserStatePath: defaultUserState,
+		JournalPath:   defaultJournal,
+		CommentsPath:  defaultComments,
+		LoginPath:     defaultLogin,
+		DiffsPath:     defaultDiffs,
 	}
 }
 
 type FileStorage struct {
 	PrsStatePath  string
 	UserStatePath string
+	// JournalPath is an append-only log of user actions, used to undo them.
+	JournalPath string
+	// CommentsPath is the directory with the cached comments, a file per PR.
+	CommentsPath string
+	// LoginPath holds the GitHub login of the user.
+	LoginPath string
+	// DiffsPath is the directory with the cached diffs, a file per PR.
+	DiffsPath string
 }
 
 var _ Storage = (*FileStorage)(nil)
@@ -36,68 +57,287 @@ func (s *FileStorage) ResetPullRequests(prs []gh.PullRequest) error {
 	return writeAtOnce(s.PrsStatePath, marshalled)
 }
 
-func (s *FileStorage) MarkUrlAsOpened(url string) (bool, error) {
-	log.Printf("Mark opened %s", url)
-	pr, err := s.getPrForUrl(url)
+func (s *FileStorage) MarkUrlsAsOpened(urls []string) (int, error) {
+	log.Printf("Mark opened %s", strings.Join(urls, ", "))
+	prs, err := s.getPrsForUrls(urls)
 	if err != nil {
-		return false, fmt.Errorf("error when marking open: %w", err)
+		return 0, fmt.Errorf("error when marking open: %w", err)
 	}
-	userPrState, err := s.readUserState()
-	if err != nil {
-		return false, fmt.Errorf("error while reading user state: %w", err)
-	}
-	prState := userPrState.GetPR(url)
-	if prState.OpenedAt != nil && *prState.OpenedAt == pr.UpdatedAt && prState.LastCommentCount == pr.CommentsCount {
-		log.Printf("PR state up to date, not marking it as opened")
-		return false, nil
-	} else {
-		log.Printf("PR state changed so it's marked as opened")
+	return s.updatePrStates(ActionOpen, urls, func(url string, prState *PrState) bool {
+		pr := prs[url]
+		if prState.OpenedAt != nil && *prState.OpenedAt == pr.UpdatedAt && prState.LastCommentCount == pr.CommentsCount {
+			log.Printf("PR state up to date, not marking it as opened: %s", url)
+			return false
+		}
+		log.Printf("PR state changed so it's marked as opened: %s", url)
+		prState.PreviousOpenedAt = prState.OpenedAt
+		prState.PreviousCommentCount = prState.LastCommentCount
 		prState.OpenedAt = &pr.UpdatedAt
 		prState.LastCommentCount = pr.CommentsCount
-		userPrState.Set(url, prState)
-		return true, s.writeUserState(userPrState)
+		return true
+	})
+}
+
+func (s *FileStorage) MarkUrlsAsUnread(urls []string) error {
+	log.Printf("Mark unread %s", strings.Join(urls, ", "))
+	_, err := s.updatePrStates(ActionUnread, urls, func(url string, prState *PrState) bool {
+		if prState.OpenedAt == nil {
+			log.Printf("PR was not opened, nothing to mark as unread: %s", url)
+			return false
+		}
+		prState.OpenedAt = prState.PreviousOpenedAt
+		prState.LastCommentCount = prState.PreviousCommentCount
+		prState.PreviousOpenedAt = nil
+		prState.PreviousCommentCount = 0
+		return true
+	})
+	return err
+}
+
+func (s *FileStorage) MarkUrlsAsMuted(urls []string) error {
+	log.Printf("Mark muted %s", strings.Join(urls, ", "))
+	now := time.Now()
+	_, err := s.updatePrStates(ActionMute, urls, func(url string, prState *PrState) bool {
+		prState.IsMute = !prState.IsMute
+		prState.MuteUpdatedAt = &now
+		log.Printf("Change mute state to %t %s", prState.IsMute, url)
+		return true
+	})
+	return err
+}
+
+func (s *FileStorage) MarkUrlsAsPinned(urls []string) error {
+	log.Printf("Mark pinned %s", strings.Join(urls, ", "))
+	now := time.Now()
+	_, err := s.updatePrStates(ActionPin, urls, func(url string, prState *PrState) bool {
+		prState.IsPinned = !prState.IsPinned
+		prState.PinUpdatedAt = &now
+		log.Printf("Change pin state to %t %s", prState.IsPinned, url)
+		return true
+	})
+	return err
+}
+
+func (s *FileStorage) getPrsForUrls(urls []string) (map[string]gh.PullRequest, error) {
+	prs, err := s.GetPullRequests()
+	if err != nil {
+		return nil, fmt.Errorf("error when marking urls: %w", err)
+	}
+	found := make(map[string]gh.PullRequest)
+	for _, pr := range prs {
+		found[pr.URL] = pr
+	}
+	for _, url := range urls {
+		if _, ok := found[url]; !ok {
+			return nil, fmt.Errorf("no such pr with url: %s", url)
+		}
 	}
+	return found, nil
+}
+
+func (s *FileStorage) AddNote(url, note string) error {
+	return s.AddNotes(map[string]string{url: note})
+}
+
+func (s *FileStorage) AddNotes(notes map[string]string) error {
+	urls := []string{}
+	for url := range notes {
+		urls = append(urls, url)
+	}
+	slices.Sort(urls)
+	now := time.Now()
+	_, err := s.updatePrStates(ActionNote, urls, func(url string, prState *PrState) bool {
+		log.Printf("Add note to URL %s: %s", url, notes[url])
+		prState.Note = notes[url]
+		prState.NoteUpdatedAt = &now
+		if notes[url] != "" {
+			prState.NoteHistory = append(prState.NoteHistory, TimedNote{Text: notes[url], CreatedAt: now})
+		}
+		return true
+	})
+	return err
+}
+
+func (s *FileStorage) AddTag(urls []string, tag string) error {
+	log.Printf("Add tag %s to %s", tag, strings.Join(urls, ", "))
+	now := time.Now()
+	_, err := s.updatePrStates(ActionTag, urls, func(url string, prState *PrState) bool {
+		if prState.HasTag(tag) {
+			return false
+		}
+		prState.Tags = append(slices.Clone(prState.Tags), tag)
+		slices.Sort(prState.Tags)
+		prState.TagsUpdatedAt = &now
+		return true
+	})
+	return err
 }
 
-func (s *FileStorage) MarkUrlAsMuted(url string) error {
-	log.Printf("Mark muted %s", url)
-	// mark as read
-	userPrState, err := s.readUserState()
+func (s *FileStorage) RemoveTag(urls []string, tag string) error {
+	log.Printf("Remove tag %s from %s", tag, strings.Join(urls, ", "))
+	now := time.Now()
+	_, err := s.updatePrStates(ActionTag, urls, func(url string, prState *PrState) bool {
+		if !prState.HasTag(tag) {
+			return false
+		}
+		prState.Tags = slices.DeleteFunc(slices.Clone(prState.Tags), func(t string) bool { return t == tag })
+		if len(prState.Tags) == 0 {
+			prState.Tags = nil
+		}
+		prState.TagsUpdatedAt = &now
+		return true
+	})
+	return err
+}
+
+func (s *FileStorage) SetViewMode(mode, sortMode string) error {
+	userState, err := s.readUserState()
 	if err != nil {
-		return fmt.Errorf("error while reading user state: %w", err)
+		return fmt.Errorf("error when setting view mode: %w", err)
+	}
+	entry := JournalEntry{
+		Time:           time.Now(),
+		Action:         ActionViewMode,
+		ViewModeBefore: userState.Settings.ViewMode,
+		ViewModeAfter:  mode,
+		SortModeBefore: userState.Settings.SortMode,
+		SortModeAfter:  sortMode,
 	}
-	prState := userPrState.GetPR(url)
-	// If already read, then change mute state
-	prState.IsMute = !prState.IsMute
-	log.Printf("Change mute state to %t %s", prState.IsMute, url)
-	userPrState.Set(url, prState)
-	return s.writeUserState(userPrState)
+	userState.Settings.ViewMode = mode
+	userState.Settings.SortMode = sortMode
+	if err := s.writeUserState(userState); err != nil {
+		return err
+	}
+	return s.appendJournal(entry)
 }
 
-func (s *FileStorage) getPrForUrl(url string) (gh.PullRequest, error) {
-	prs, err := s.GetPullRequests()
+// SetPreviewMode sets what the preview shows. It is not recorded in the journal, as it does not change the list.
+func (s *FileStorage) SetPreviewMode(mode string) error {
+	userState, err := s.readUserState()
 	if err != nil {
-		return gh.PullRequest{}, fmt.Errorf("error when marking url %s: %w", url, err)
+		return fmt.Errorf("error when setting preview mode: %w", err)
 	}
+	userState.Settings.PreviewMode = mode
+	return s.writeUserState(userState)
+}
 
-	for _, pr := range prs {
-		if pr.URL == url {
-			return pr, nil
+func (s *FileStorage) SetSortMode(mode string) error {
+	userState, err := s.readUserState()
+	if err != nil {
+		return fmt.Errorf("error when setting sort mode: %w", err)
+	}
+	before := userState.Settings.SortMode
+	userState.Settings.SortMode = mode
+	if err := s.writeUserState(userState); err != nil {
+		return err
+	}
+	return s.appendJournal(JournalEntry{
+		Time:           time.Now(),
+		Action:         ActionSortMode,
+		SortModeBefore: before,
+		SortModeAfter:  mode,
+	})
+}
+
+// updatePrStates applies the update to the state of each of the PRs in a single read and write of the user state.
+// The update returns false if it did not change the state. All the changes are recorded in the journal as a single
+// action. The method returns the number of changed PRs.
+func (s *FileStorage) updatePrStates(action string, urls []string, update func(url string, prState *PrState) bool) (int, error) {
+	userState, err := s.readUserState()
+	if err != nil {
+		return 0, fmt.Errorf("error while reading user state: %w", err)
+	}
+	changes := []PrChange{}
+	for _, url := range urls {
+		before := userState.lookupPR(url)
+		prState := userState.GetPR(url)
+		if !update(url, &prState) {
+			continue
 		}
+		userState.Set(url, prState)
+		changes = append(changes, PrChange{Url: url, Before: before, After: userState.lookupPR(url)})
 	}
-	return gh.PullRequest{}, fmt.Errorf("no such pr with url: %s", url)
+	if len(changes) == 0 {
+		return 0, nil
+	}
+	if err := s.writeUserState(userState); err != nil {
+		return 0, err
+	}
+	return len(changes), s.appendJournal(JournalEntry{
+		Time:    time.Now(),
+		Action:  action,
+		Changes: changes,
+	})
 }
 
-func (s *FileStorage) AddNote(url, note string) error {
-	log.Printf("Add note to URL %s: %s", url, note)
-	userPrState, err := s.readUserState()
+func (s *FileStorage) GetComments(url string) (*CommentThread, error) {
+	var thread CommentThread
+	if ok, err := readCached(s.CommentsPath, url, &thread); !ok || err != nil {
+		return nil, err
+	}
+	return &thread, nil
+}
+
+func (s *FileStorage) SetComments(url string, thread CommentThread) error {
+	return writeCached(s.CommentsPath, url, thread)
+}
+
+func (s *FileStorage) GetDiff(url string) (*CachedDiff, error) {
+	var diff CachedDiff
+	if ok, err := readCached(s.DiffsPath, url, &diff); !ok || err != nil {
+		return nil, err
+	}
+	return &diff, nil
+}
+
+func (s *FileStorage) SetDiff(url string, diff CachedDiff) error {
+	return writeCached(s.DiffsPath, url, diff)
+}
+
+// readCached reads the value cached for the PR in the directory. It returns false if nothing is cached.
+func readCached(dir, url string, v any) (bool, error) {
+	target := cacheFile(dir, url)
+	log.Printf("Read %s", target)
+	b, err := os.ReadFile(target)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("error while reading %s: %w", target, err)
+	}
+	if err := json.Unmarshal(b, v); err != nil {
+		return false, fmt.Errorf("error while unmarshalling file %s: %w", target, err)
+	}
+	return true, nil
+}
+
+func writeCached(dir, url string, v any) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("error while making directory %s: %w", dir, err)
+	}
+	marshalled, err := json.MarshalIndent(v, "", " ")
 	if err != nil {
-		return fmt.Errorf("error when adding note: %w", err)
+		return fmt.Errorf("error while marshalling cached value: %w", err)
+	}
+	return writeAtOnce(cacheFile(dir, url), marshalled)
+}
+
+// cacheFile returns the file of the value cached for the PR in the directory, named by the hash of the URL.
+func cacheFile(dir, url string) string {
+	return filepath.Join(dir, fmt.Sprintf("%x.json", sha256.Sum256([]byte(url))))
+}
+
+func (s *FileStorage) GetLogin() (string, error) {
+	b, err := os.ReadFile(s.LoginPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	} else if err != nil {
+		return "", fmt.Errorf("error while reading %s: %w", s.LoginPath, err)
 	}
-	prState := userPrState.GetPR(url)
-	prState.Note = note
-	userPrState.Set(url, prState)
-	return s.writeUserState(userPrState)
+	return strings.TrimSpace(string(b)), nil
+}
+
+func (s *FileStorage) SetLogin(login string) error {
+	return writeAtOnce(s.LoginPath, []byte(login+"\n"))
 }
 
 func (s *FileStorage) GetPullRequests() ([]gh.PullRequest, error) {
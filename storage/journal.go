@@ -0,0 +1,133 @@
+package storage
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+)
+
+const (
+	ActionOpen     = "open"
+	ActionUnread   = "unread"
+	ActionMute     = "mute"
+	ActionNote     = "note"
+	ActionPin      = "pin"
+	ActionTag      = "tag"
+	ActionViewMode = "view-mode"
+	ActionSortMode = "sort-mode"
+	ActionUndo     = "undo"
+)
+
+// JournalEntry is a single user action, possibly applied to many PRs at once.
+type JournalEntry struct {
+	Time           time.Time
+	Action         string
+	Changes        []PrChange `json:",omitempty"`
+	ViewModeBefore string     `json:",omitempty"`
+	ViewModeAfter  string     `json:",omitempty"`
+	SortModeBefore string     `json:",omitempty"`
+	SortModeAfter  string     `json:",omitempty"`
+}
+
+// PrChange holds the state of a PR before and after an action, nil meaning that there was no state for the PR.
+type PrChange struct {
+	Url    string
+	Before *PrState `json:",omitempty"`
+	After  *PrState `json:",omitempty"`
+}
+
+func (s *FileStorage) appendJournal(entry JournalEntry) error {
+	log.Printf("Journal action %s on %d PRs", entry.Action, len(entry.Changes))
+	marshalled, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("error while marshalling journal entry: %w", err)
+	}
+	f, err := os.OpenFile(s.JournalPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("error while opening journal %s: %w", s.JournalPath, err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(marshalled, '\n')); err != nil {
+		return fmt.Errorf("error while writing journal %s: %w", s.JournalPath, err)
+	}
+	return nil
+}
+
+func (s *FileStorage) readJournal() ([]JournalEntry, error) {
+	f, err := os.Open(s.JournalPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error while reading journal %s: %w", s.JournalPath, err)
+	}
+	defer f.Close()
+	entries := []JournalEntry{}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		var entry JournalEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("error while unmarshalling journal %s: %w", s.JournalPath, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+// pendingActions returns the actions that can still be undone, the most recent last. Each undo entry in the journal
+// cancels the most recent action that was not undone yet.
+func pendingActions(entries []JournalEntry) []JournalEntry {
+	stack := []JournalEntry{}
+	for _, e := range entries {
+		if e.Action != ActionUndo {
+			stack = append(stack, e)
+		} else if len(stack) > 0 {
+			stack = stack[:len(stack)-1]
+		}
+	}
+	return stack
+}
+
+func (s *FileStorage) Undo() (*JournalEntry, error) {
+	entries, err := s.readJournal()
+	if err != nil {
+		return nil, fmt.Errorf("error when undoing: %w", err)
+	}
+	pending := pendingActions(entries)
+	if len(pending) == 0 {
+		log.Printf("Nothing to undo")
+		return nil, nil
+	}
+	last := pending[len(pending)-1]
+	log.Printf("Undo %s from %s", last.Action, last.Time)
+	userState, err := s.readUserState()
+	if err != nil {
+		return nil, fmt.Errorf("error when undoing: %w", err)
+	}
+	if last.Action == ActionViewMode {
+		userState.Settings.ViewMode = last.ViewModeBefore
+		userState.Settings.SortMode = last.SortModeBefore
+	} else if last.Action == ActionSortMode {
+		userState.Settings.SortMode = last.SortModeBefore
+	}
+	for _, change := range last.Changes {
+		if change.Before == nil {
+			delete(userState.PerUrl, change.Url)
+		} else {
+			userState.Set(change.Url, *change.Before)
+		}
+	}
+	if err := s.writeUserState(userState); err != nil {
+		return nil, err
+	}
+	undo := JournalEntry{Time: time.Now(), Action: ActionUndo}
+	if err := s.appendJournal(undo); err != nil {
+		return nil, err
+	}
+	return &last, nil
+}
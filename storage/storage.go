@@ -9,12 +9,50 @@ type Storage interface {
 	// ResetPullRequests purges the storage and sets the new pull request.
 	ResetPullRequests(prs []gh.PullRequest) error
 	GetPullRequests() ([]gh.PullRequest, error)
-	// MarkUrlAsOpened return boolean true if the file was marked as open and false if it was already marked.
-	MarkUrlAsOpened(url string) (bool, error)
-	MarkUrlAsMuted(url string) error
+	// MarkUrlsAsOpened returns the number of PRs that were marked as open. The PRs that were already marked are
+	// not counted.
+	MarkUrlsAsOpened(urls []string) (int, error)
+	// MarkUrlsAsUnread restores the read marker from before the PRs were last opened.
+	MarkUrlsAsUnread(urls []string) error
+	MarkUrlsAsMuted(urls []string) error
+	// MarkUrlsAsPinned toggles the pin state of the PRs.
+	MarkUrlsAsPinned(urls []string) error
 	GetUserState() (*UserState, error)
 	WriteUserState(s *UserState) error
 	// GetSyncTime returns last time the state was synchronised and ok (bool) if it was synchronised at all.
 	GetSyncTime() (time.Time, bool)
 	AddNote(url, note string) error
+	// AddNotes sets the current notes per URL in a single update, and appends them to the note history.
+	AddNotes(notes map[string]string) error
+	AddTag(urls []string, tag string) error
+	RemoveTag(urls []string, tag string) error
+	// SetViewMode sets the view mode together with the sort mode of the view.
+	SetViewMode(mode, sortMode string) error
+	SetSortMode(mode string) error
+	SetPreviewMode(mode string) error
+	// Undo reverts the last user action that was not undone yet. It returns the reverted action, or nil if there
+	// is nothing to undo.
+	Undo() (*JournalEntry, error)
+	// GetComments returns the cached comments of the PR, or nil if they were not cached.
+	GetComments(url string) (*CommentThread, error)
+	SetComments(url string, thread CommentThread) error
+	// GetLogin returns the GitHub login of the user resolved by sync, or empty if it was not resolved yet.
+	GetLogin() (string, error)
+	SetLogin(login string) error
+	// GetDiff returns the cached diff of the PR, or nil if it was not cached.
+	GetDiff(url string) (*CachedDiff, error)
+	SetDiff(url string, diff CachedDiff) error
+}
+
+// CommentThread are the comments of a PR, cached as of the PR update time.
+type CommentThread struct {
+	UpdatedAt time.Time
+	Comments  []gh.Comment
+}
+
+// CachedDiff is the diff of a PR at the head commit. UpdatedAt is the PR update time when the head was last checked.
+type CachedDiff struct {
+	UpdatedAt time.Time
+	HeadSha   string
+	Diff      string
 }
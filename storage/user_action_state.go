@@ -2,6 +2,7 @@ package storage
 
 import (
 	"ffgh/gh"
+	"slices"
 	"time"
 )
 
@@ -12,6 +13,11 @@ type UserState struct {
 
 type UserSettings struct {
 	ViewMode string
+	SortMode string `json:",omitempty"`
+	// TagFilter, if set, shows only the PRs with the tag.
+	TagFilter string `json:",omitempty"`
+	// PreviewMode is what the preview shows, the PR details by default.
+	PreviewMode string `json:",omitempty"`
 }
 
 // GetPR is deprecated.
@@ -25,6 +31,14 @@ func (s *UserState) GetPR(url string) PrState {
 	}
 }
 
+// lookupPR returns a copy of the PR state, or nil if there is no state for the URL.
+func (s *UserState) lookupPR(url string) *PrState {
+	if state, ok := s.PerUrl[url]; ok {
+		return &state
+	}
+	return nil
+}
+
 func (s *UserState) Set(url string, p PrState) {
 	s.PerUrl[url] = p
 }
@@ -32,8 +46,35 @@ func (s *UserState) Set(url string, p PrState) {
 type PrState struct {
 	OpenedAt         *time.Time
 	LastCommentCount int
-	Note             string
-	IsMute           bool
+	// PreviousOpenedAt and PreviousCommentCount hold the read marker from before the last opening, so the PR can be
+	// marked as unread again.
+	PreviousOpenedAt     *time.Time `json:",omitempty"`
+	PreviousCommentCount int        `json:",omitempty"`
+	// Note is the current note, shown in the list.
+	Note string
+	// NoteUpdatedAt is when the note was last changed. It is used to resolve conflicts on state import.
+	NoteUpdatedAt *time.Time
+	// NoteHistory holds all the non-empty notes ever added, the oldest first.
+	NoteHistory []TimedNote `json:",omitempty"`
+	Tags        []string    `json:",omitempty"`
+	// TagsUpdatedAt is when the tags were last changed. It is used to resolve conflicts on state import.
+	TagsUpdatedAt *time.Time `json:",omitempty"`
+	IsMute        bool
+	// MuteUpdatedAt is when the PR was last muted or unmuted. It is used to resolve conflicts on state import.
+	MuteUpdatedAt *time.Time `json:",omitempty"`
+	// IsPinned says if the PR is always shown at the top of the list.
+	IsPinned bool `json:",omitempty"`
+	// PinUpdatedAt is when the PR was last pinned or unpinned. It is used to resolve conflicts on state import.
+	PinUpdatedAt *time.Time `json:",omitempty"`
+}
+
+type TimedNote struct {
+	Text      string
+	CreatedAt time.Time
+}
+
+func (p PrState) HasTag(tag string) bool {
+	return slices.Contains(p.Tags, tag)
 }
 
 const (
@@ -42,15 +83,29 @@ const (
 	IS_NEW
 )
 
+// GetPrStateFlags returns the flags of the PR compared to when it was last opened. The user's own comments and updates
+// are not flagged.
 func GetPrStateFlags(pr gh.PullRequest, prState PrState) int {
 	out := 0
-	if pr.CommentsCount > prState.LastCommentCount {
+	if NewCommentCount(pr, prState) > 0 {
 		out |= HAS_NEW_COMMENTS
 	}
 	if prState.OpenedAt == nil {
 		out |= IS_NEW
-	} else if pr.UpdatedAt.After(*prState.OpenedAt) {
+	} else if pr.UpdatedAt.After(*prState.OpenedAt) && !pr.Meta.IsOwnUpdate {
+		// The PR updated only by the user is not shown as updated.
 		out |= IS_UPDATED
 	}
 	return out
 }
+
+// NewCommentCount returns the number of comments since the PR was last opened, not counting the user's own comments.
+func NewCommentCount(pr gh.PullRequest, prState PrState) int {
+	count := pr.CommentsCount - prState.LastCommentCount
+	for _, t := range pr.Meta.OwnCommentTimes {
+		if prState.OpenedAt == nil || t.After(*prState.OpenedAt) {
+			count--
+		}
+	}
+	return max(0, count)
+}
@@ -0,0 +1,89 @@
+package sync
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"ffgh/gh"
+	"ffgh/storage"
+	"fmt"
+	"io"
+	"log"
+	"os/exec"
+	"slices"
+	"time"
+)
+
+// restComment is a comment in the payload of the GitHub REST API.
+type restComment struct {
+	User struct {
+		Login string `json:"login"`
+		Type  string `json:"type"`
+	} `json:"user"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+	HtmlUrl   string    `json:"html_url"`
+	Path      string    `json:"path"`
+}
+
+// GetComments returns the comments of the PR, oldest first. The comments are fetched from GitHub only if the PR was
+// updated since they were cached. If fetching fails, the outdated cached comments are returned, if there are any.
+func GetComments(st storage.Storage, pr gh.PullRequest) ([]gh.Comment, error) {
+	cached, err := st.GetComments(pr.URL)
+	if err != nil {
+		log.Printf("Could not read cached comments of %s: %s", pr.URL, err)
+	}
+	if cached != nil && cached.UpdatedAt.Equal(pr.UpdatedAt) {
+		return cached.Comments, nil
+	}
+	comments, err := FetchComments(pr)
+	if err != nil {
+		if cached != nil {
+			log.Printf("Use outdated comments of %s: %s", pr.URL, err)
+			return cached.Comments, nil
+		}
+		return nil, err
+	}
+	if err := st.SetComments(pr.URL, storage.CommentThread{UpdatedAt: pr.UpdatedAt, Comments: comments}); err != nil {
+		log.Printf("Could not cache comments of %s: %s", pr.URL, err)
+	}
+	return comments, nil
+}
+
+// FetchComments fetches the conversation comments and the review comments of the PR, oldest first.
+func FetchComments(pr gh.PullRequest) ([]gh.Comment, error) {
+	comments := []gh.Comment{}
+	for _, kind := range []string{"issues", "pulls"} {
+		endpoint := fmt.Sprintf("repos/%s/%s/%d/comments", pr.Repository.NameWithOwner, kind, pr.Number)
+		log.Printf("Get comments: %s", endpoint)
+		out, err := exec.Command("gh", "api", "--paginate", endpoint).Output()
+		if err != nil {
+			return nil, fmt.Errorf("error while running gh command: %s", err)
+		}
+		// With --paginate the output is a JSON array per page.
+		decoder := json.NewDecoder(bytes.NewReader(out))
+		for {
+			var page []restComment
+			if err := decoder.Decode(&page); errors.Is(err, io.EOF) {
+				break
+			} else if err != nil {
+				return nil, fmt.Errorf("error while interpreting JSON output of gh command: %s", err)
+			}
+			for _, c := range page {
+				comments = append(comments, gh.Comment{
+					Author: gh.Author{
+						Login: c.User.Login,
+						IsBot: c.User.Type == "Bot",
+						Type:  c.User.Type,
+					},
+					Body:      c.Body,
+					CreatedAt: c.CreatedAt,
+					URL:       c.HtmlUrl,
+					Path:      c.Path,
+				})
+			}
+		}
+	}
+	slices.SortStableFunc(comments, func(a, b gh.Comment) int { return a.CreatedAt.Compare(b.CreatedAt) })
+	return comments, nil
+}
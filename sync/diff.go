@@ -0,0 +1,54 @@
+package sync
+
+import (
+	"ffgh/gh"
+	"ffgh/storage"
+	"fmt"
+	"log"
+	"os/exec"
+	"strings"
+)
+
+// GetDiff returns the diff of the PR. The cached diff is used if the PR was not updated since it was cached, or if the
+// head commit is still the same. If fetching fails, the outdated cached diff is returned, if there is one.
+func GetDiff(st storage.Storage, pr gh.PullRequest) (string, error) {
+	cached, err := st.GetDiff(pr.URL)
+	if err != nil {
+		log.Printf("Could not read cached diff of %s: %s", pr.URL, err)
+	}
+	if cached != nil && cached.UpdatedAt.Equal(pr.UpdatedAt) {
+		return cached.Diff, nil
+	}
+	headSha, err := getHeadSha(pr)
+	if err != nil {
+		if cached != nil {
+			log.Printf("Use outdated diff of %s: %s", pr.URL, err)
+			return cached.Diff, nil
+		}
+		return "", err
+	}
+	diff := ""
+	if cached != nil && cached.HeadSha == headSha {
+		log.Printf("Head of %s not changed: %s", pr.URL, headSha)
+		diff = cached.Diff
+	} else {
+		log.Printf("Get diff of %s at %s", pr.URL, headSha)
+		out, err := exec.Command("gh", "pr", "diff", pr.URL, "--color=never").Output()
+		if err != nil {
+			return "", fmt.Errorf("error while running gh command: %s", err)
+		}
+		diff = string(out)
+	}
+	if err := st.SetDiff(pr.URL, storage.CachedDiff{UpdatedAt: pr.UpdatedAt, HeadSha: headSha, Diff: diff}); err != nil {
+		log.Printf("Could not cache diff of %s: %s", pr.URL, err)
+	}
+	return diff, nil
+}
+
+func getHeadSha(pr gh.PullRequest) (string, error) {
+	out, err := exec.Command("gh", "pr", "view", pr.URL, "--json", "headRefOid", "--jq", ".headRefOid").Output()
+	if err != nil {
+		return "", fmt.Errorf("error while running gh command: %s", err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
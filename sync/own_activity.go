@@ -0,0 +1,179 @@
+package sync
+
+import (
+	"encoding/json"
+	"ffgh/config"
+	"ffgh/gh"
+	"ffgh/storage"
+	"fmt"
+	"log"
+	"os/exec"
+	"strings"
+)
+
+// lastActivityQuery gets the author of the last item of the PR timeline.
+const lastActivityQuery = `query($owner: String!, $repo: String!, $number: Int!) {
+  repository(owner: $owner, name: $repo) {
+    pullRequest(number: $number) {
+      timelineItems(last: 1) {
+        nodes {
+          ... on IssueComment { author { login } }
+          ... on PullRequestReview { author { login } }
+          ... on PullRequestCommit { commit { author { user { login } } } }
+          ... on HeadRefForcePushedEvent { actor { login } }
+          ... on ReviewRequestedEvent { actor { login } }
+          ... on ReadyForReviewEvent { actor { login } }
+          ... on LabeledEvent { actor { login } }
+          ... on RenamedTitleEvent { actor { login } }
+        }
+      }
+    }
+  }
+}`
+
+type login struct {
+	Login string `json:"login"`
+}
+
+// resolveLogin returns the login from the config, or the login of the user authenticated in gh. The resolved login is
+// stored so that the other commands can use it. It returns empty login if it could not be resolved.
+func (s *Synchronizer) resolveLogin(config config.Config) string {
+	if config.Login != "" {
+		return config.Login
+	}
+	if s.login != "" {
+		return s.login
+	}
+	log.Printf("Resolve login")
+	out, err := exec.Command("gh", "api", "user", "--jq", ".login").Output()
+	if err != nil {
+		log.Printf("Could not resolve login: %s", err)
+		return ""
+	}
+	s.login = strings.TrimSpace(string(out))
+	log.Printf("Resolved login: %s", s.login)
+	if err := s.Storage.SetLogin(s.login); err != nil {
+		log.Printf("Could not store login: %s", err)
+	}
+	return s.login
+}
+
+// findOwnUpdates sets if the last activity was the user's own for the PRs that are updated since last opened. The
+// activity is fetched only for the PRs updated since the last sync.
+func (s *Synchronizer) findOwnUpdates(prs []gh.PullRequest, login string) {
+	userState, err := s.Storage.GetUserState()
+	if err != nil {
+		log.Printf("Could not read user state, not looking for own updates: %s", err)
+		return
+	}
+	previous := s.previousPrs()
+	for i, pr := range prs {
+		if storage.GetPrStateFlags(pr, userState.PerUrl[pr.URL])&storage.IS_UPDATED == 0 {
+			continue
+		}
+		author := ""
+		if p, ok := previous[pr.URL]; ok && p.UpdatedAt.Equal(pr.UpdatedAt) && p.Meta.LastActivityBy != "" {
+			author = p.Meta.LastActivityBy
+		} else if author, err = getLastActivityAuthor(pr); err != nil {
+			log.Printf("Could not get last activity of %s: %s", pr.URL, err)
+			continue
+		}
+		prs[i].Meta.LastActivityBy = author
+		prs[i].Meta.IsOwnUpdate = author != "" && strings.EqualFold(author, login)
+	}
+}
+
+// markOwnNewPrsRead marks the new PRs opened by the user as read, as the user knows about them already.
+func (s *Synchronizer) markOwnNewPrsRead(prs []gh.PullRequest, login string) {
+	userState, err := s.Storage.GetUserState()
+	if err != nil {
+		log.Printf("Could not read user state, not marking own PRs: %s", err)
+		return
+	}
+	urls := []string{}
+	for _, pr := range prs {
+		if strings.EqualFold(pr.Author.Login, login) && storage.GetPrStateFlags(pr, userState.PerUrl[pr.URL])&storage.IS_NEW != 0 {
+			urls = append(urls, pr.URL)
+		}
+	}
+	if len(urls) == 0 {
+		return
+	}
+	log.Printf("Mark own new PRs as read: %s", strings.Join(urls, ", "))
+	if _, err := s.Storage.MarkUrlsAsOpened(urls); err != nil {
+		log.Printf("Could not mark own PRs as read: %s", err)
+	}
+}
+
+// previousPrs returns the PRs of the previous sync by URL, so that the details fetched for them can be reused if the PRs
+// were not updated since.
+func (s *Synchronizer) previousPrs() map[string]gh.PullRequest {
+	previous := make(map[string]gh.PullRequest)
+	prs, err := s.Storage.GetPullRequests()
+	if err != nil {
+		log.Printf("Could not read previous PRs: %s", err)
+		return previous
+	}
+	for _, pr := range prs {
+		previous[pr.URL] = pr
+	}
+	return previous
+}
+
+// queryPr runs the GraphQL query with the owner, repo and number variables of the PR.
+func queryPr(query string, pr gh.PullRequest) ([]byte, error) {
+	owner, repo, _ := strings.Cut(pr.Repository.NameWithOwner, "/")
+	out, err := exec.Command("gh", "api", "graphql",
+		"-f", "query="+query,
+		"-f", "owner="+owner,
+		"-f", "repo="+repo,
+		"-F", fmt.Sprintf("number=%d", pr.Number),
+	).Output()
+	if err != nil {
+		return nil, fmt.Errorf("error while running gh command: %s", err)
+	}
+	return out, nil
+}
+
+func getLastActivityAuthor(pr gh.PullRequest) (string, error) {
+	out, err := queryPr(lastActivityQuery, pr)
+	if err != nil {
+		return "", err
+	}
+	var response struct {
+		Data struct {
+			Repository struct {
+				PullRequest struct {
+					TimelineItems struct {
+						Nodes []struct {
+							Author *login `json:"author"`
+							Actor  *login `json:"actor"`
+							Commit *struct {
+								Author struct {
+									User *login `json:"user"`
+								} `json:"author"`
+							} `json:"commit"`
+						} `json:"nodes"`
+					} `json:"timelineItems"`
+				} `json:"pullRequest"`
+			} `json:"repository"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(out, &response); err != nil {
+		return "", fmt.Errorf("error while interpreting JSON output of gh command: %s\n\n%s", err, out)
+	}
+	nodes := response.Data.Repository.PullRequest.TimelineItems.Nodes
+	if len(nodes) == 0 {
+		return "", nil
+	}
+	node := nodes[len(nodes)-1]
+	switch {
+	case node.Author != nil:
+		return node.Author.Login, nil
+	case node.Actor != nil:
+		return node.Actor.Login, nil
+	case node.Commit != nil && node.Commit.Author.User != nil:
+		return node.Commit.Author.User.Login, nil
+	}
+	return "", nil
+}
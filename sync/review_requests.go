@@ -0,0 +1,94 @@
+package sync
+
+import (
+	"encoding/json"
+	"ffgh/gh"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// reviewRequestsQuery gets the review requests of the PR, with the requested user or team.
+const reviewRequestsQuery = `query($owner: String!, $repo: String!, $number: Int!) {
+  repository(owner: $owner, name: $repo) {
+    pullRequest(number: $number) {
+      timelineItems(itemTypes: [REVIEW_REQUESTED_EVENT], last: 50) {
+        nodes {
+          ... on ReviewRequestedEvent {
+            createdAt
+            requestedReviewer {
+              ... on User { login }
+              ... on Team { slug }
+            }
+          }
+        }
+      }
+    }
+  }
+}`
+
+// findReviewRequests sets when the user's review was last requested for the PRs of the other authors. The requests
+// are fetched only for the PRs updated since the last sync.
+func (s *Synchronizer) findReviewRequests(prs []gh.PullRequest, login string) {
+	previous := s.previousPrs()
+	for i, pr := range prs {
+		if strings.EqualFold(pr.Author.Login, login) {
+			continue
+		}
+		if p, ok := previous[pr.URL]; ok && p.UpdatedAt.Equal(pr.UpdatedAt) && p.Meta.ReviewRequestsChecked {
+			prs[i].Meta.ReviewRequestedAt = p.Meta.ReviewRequestedAt
+			prs[i].Meta.ReviewRequestsChecked = true
+			continue
+		}
+		requestedAt, err := getReviewRequestedAt(pr, login)
+		if err != nil {
+			log.Printf("Could not get review requests of %s: %s", pr.URL, err)
+			continue
+		}
+		prs[i].Meta.ReviewRequestedAt = requestedAt
+		prs[i].Meta.ReviewRequestsChecked = true
+	}
+}
+
+// getReviewRequestedAt returns the time of the last review request of the user. The team requests are used only if
+// the user was not requested directly, since the teams of the user are not known.
+func getReviewRequestedAt(pr gh.PullRequest, login string) (*time.Time, error) {
+	out, err := queryPr(reviewRequestsQuery, pr)
+	if err != nil {
+		return nil, err
+	}
+	var response struct {
+		Data struct {
+			Repository struct {
+				PullRequest struct {
+					TimelineItems struct {
+						Nodes []struct {
+							CreatedAt         time.Time `json:"createdAt"`
+							RequestedReviewer struct {
+								Login string `json:"login"`
+								Slug  string `json:"slug"`
+							} `json:"requestedReviewer"`
+						} `json:"nodes"`
+					} `json:"timelineItems"`
+				} `json:"pullRequest"`
+			} `json:"repository"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(out, &response); err != nil {
+		return nil, fmt.Errorf("error while interpreting JSON output of gh command: %s\n\n%s", err, out)
+	}
+	var user, team *time.Time
+	for _, node := range response.Data.Repository.PullRequest.TimelineItems.Nodes {
+		createdAt := node.CreatedAt
+		if strings.EqualFold(node.RequestedReviewer.Login, login) {
+			user = &createdAt
+		} else if node.RequestedReviewer.Slug != "" {
+			team = &createdAt
+		}
+	}
+	if user != nil {
+		return user, nil
+	}
+	return team, nil
+}
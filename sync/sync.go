@@ -15,9 +15,11 @@ import (
 type Synchronizer struct {
 	Storage  storage.Storage
 	Interval time.Duration
+	// login is the GitHub login of the user, resolved once.
+	login string
 }
 
-const jsonFields = "author,body,commentsCount,createdAt,id,number,repository,state,title,updatedAt,url"
+const jsonFields = "author,body,commentsCount,createdAt,id,labels,number,repository,state,title,updatedAt,url"
 
 // assignees
 // author
@@ -90,14 +92,49 @@ func (s *Synchronizer) RunOnce(config config.Config) error {
 		}
 	}
 
+	login := s.resolveLogin(config)
+	if login != "" {
+		s.findOwnComments(uniquePrs, login)
+		s.findOwnUpdates(uniquePrs, login)
+		s.findReviewRequests(uniquePrs, login)
+	}
+
 	if err := s.Storage.ResetPullRequests(uniquePrs); err != nil {
 		return fmt.Errorf("error while storing PRs: %w", err)
 	}
+	if login != "" {
+		s.markOwnNewPrsRead(uniquePrs, login)
+	}
 
 	log.Printf("Updated %d pull requests", len(uniquePrs))
 	return nil
 }
 
+// findOwnComments sets the times of the user's own comments of the PRs with new comments, so that they are not counted
+// as new. The comments are fetched only for the PRs updated since their comments were cached.
+func (s *Synchronizer) findOwnComments(prs []gh.PullRequest, login string) {
+	userState, err := s.Storage.GetUserState()
+	if err != nil {
+		log.Printf("Could not read user state, not looking for own comments: %s", err)
+		return
+	}
+	for i, pr := range prs {
+		if storage.GetPrStateFlags(pr, userState.PerUrl[pr.URL])&storage.HAS_NEW_COMMENTS == 0 {
+			continue
+		}
+		comments, err := GetComments(s.Storage, pr)
+		if err != nil {
+			log.Printf("Could not get comments of %s: %s", pr.URL, err)
+			continue
+		}
+		for _, c := range comments {
+			if c.Path == "" && strings.EqualFold(c.Author.Login, login) {
+				prs[i].Meta.OwnCommentTimes = append(prs[i].Meta.OwnCommentTimes, c.CreatedAt)
+			}
+		}
+	}
+}
+
 func selectPrWrtAttributionPriority(prs []gh.PullRequest, attributionPriority map[string]int) gh.PullRequest {
 	selected := prs[0]
 	for _, pr := range prs {
@@ -0,0 +1,206 @@
+package theme
+
+import (
+	"fmt"
+	"maps"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// Role is the meaning of the styled text, like a new PR flag or a note.
+type Role string
+
+const (
+	New       Role = "new"
+	Updated   Role = "updated"
+	Comments  Role = "comments"
+	Pinned    Role = "pinned"
+	Note      Role = "note"
+	Tags      Role = "tags"
+	Muted     Role = "muted"
+	Repo      Role = "repo"
+	Title     Role = "title"
+	Author    Role = "author"
+	Details   Role = "details"
+	Header    Role = "header"
+	Timestamp Role = "timestamp"
+	Own       Role = "own"
+	Bot       Role = "bot"
+	Heading   Role = "heading"
+	Code      Role = "code"
+	Link      Role = "link"
+	Added     Role = "added"
+	Removed   Role = "removed"
+	Hunk      Role = "hunk"
+	Cursor    Role = "cursor"
+	Selected  Role = "selected"
+)
+
+const (
+	Dark       = "dark"
+	Light      = "light"
+	Monochrome = "monochrome"
+)
+
+// Theme maps the roles to the styles. A style is a space separated list of attributes, like "hi-red bold".
+type Theme map[Role]string
+
+var builtinThemes = map[string]Theme{
+	Dark: {
+		New:       "green",
+		Updated:   "hi-white",
+		Comments:  "hi-yellow",
+		Pinned:    "magenta",
+		Note:      "cyan",
+		Tags:      "blue",
+		Muted:     "hi-black",
+		Repo:      "hi-red",
+		Title:     "cyan",
+		Author:    "yellow",
+		Details:   "yellow",
+		Header:    "hi-blue bold",
+		Timestamp: "hi-black",
+		Own:       "hi-green",
+		Bot:       "hi-black",
+		Heading:   "hi-white bold",
+		Code:      "hi-cyan",
+		Link:      "blue underline",
+		Added:     "green",
+		Removed:   "red",
+		Hunk:      "cyan",
+		Cursor:    "red bold",
+		Selected:  "magenta",
+	},
+	Light: {
+		New:       "green bold",
+		Updated:   "blue bold",
+		Comments:  "magenta bold",
+		Pinned:    "magenta",
+		Note:      "blue",
+		Tags:      "cyan",
+		Muted:     "faint",
+		Repo:      "red",
+		Title:     "blue",
+		Author:    "black bold",
+		Details:   "black",
+		Header:    "blue bold",
+		Timestamp: "faint",
+		Own:       "green bold",
+		Bot:       "faint",
+		Heading:   "bold",
+		Code:      "magenta",
+		Link:      "blue underline",
+		Added:     "green",
+		Removed:   "red",
+		Hunk:      "blue",
+		Cursor:    "red bold",
+		Selected:  "magenta",
+	},
+	Monochrome: {
+		New:       "bold",
+		Updated:   "underline",
+		Comments:  "bold underline",
+		Pinned:    "reverse",
+		Note:      "italic",
+		Tags:      "italic",
+		Muted:     "faint",
+		Repo:      "bold",
+		Title:     "bold",
+		Author:    "",
+		Details:   "",
+		Header:    "bold underline",
+		Timestamp: "faint",
+		Own:       "bold",
+		Bot:       "faint",
+		Heading:   "bold",
+		Code:      "italic",
+		Link:      "underline",
+		Added:     "bold",
+		Removed:   "faint",
+		Hunk:      "italic",
+		Cursor:    "bold",
+		Selected:  "bold",
+	},
+}
+
+var attributes = func() map[string]color.Attribute {
+	attrs := map[string]color.Attribute{
+		"bold":      color.Bold,
+		"faint":     color.Faint,
+		"italic":    color.Italic,
+		"underline": color.Underline,
+		"reverse":   color.ReverseVideo,
+	}
+	colorNames := []string{"black", "red", "green", "yellow", "blue", "magenta", "cyan", "white"}
+	for i, name := range colorNames {
+		attrs[name] = color.FgBlack + color.Attribute(i)
+		attrs["hi-"+name] = color.FgHiBlack + color.Attribute(i)
+		attrs["bg-"+name] = color.BgBlack + color.Attribute(i)
+		attrs["bg-hi-"+name] = color.BgHiBlack + color.Attribute(i)
+	}
+	return attrs
+}()
+
+// current is the theme used by S. It is set once on start.
+var current = compile(builtinThemes[Dark])
+
+// Set makes the theme current. The base is the name of a built-in theme, dark by default, and the overrides replace
+// the styles of the base theme for some roles.
+func Set(base string, overrides map[string]string) error {
+	if base == "" {
+		base = Dark
+	}
+	t, ok := builtinThemes[base]
+	if !ok {
+		return fmt.Errorf("unknown theme: %s", base)
+	}
+	t = maps.Clone(t)
+	for role, style := range overrides {
+		if _, ok := t[Role(role)]; !ok {
+			return fmt.Errorf("unknown role in theme: %s", role)
+		}
+		t[Role(role)] = style
+	}
+	compiled, err := compileStrict(t)
+	if err != nil {
+		return err
+	}
+	current = compiled
+	return nil
+}
+
+// S styles the string according to the role.
+func S(role Role, s string) string {
+	c, ok := current[role]
+	if !ok {
+		return s
+	}
+	return c.Sprint(s)
+}
+
+func compile(t Theme) map[Role]*color.Color {
+	compiled, err := compileStrict(t)
+	if err != nil {
+		panic(err)
+	}
+	return compiled
+}
+
+func compileStrict(t Theme) (map[Role]*color.Color, error) {
+	compiled := make(map[Role]*color.Color)
+	for role, style := range t {
+		attrs := []color.Attribute{}
+		for _, word := range strings.Fields(style) {
+			a, ok := attributes[word]
+			if !ok {
+				return nil, fmt.Errorf("unknown style %q for %s", word, role)
+			}
+			attrs = append(attrs, a)
+		}
+		if len(attrs) > 0 {
+			compiled[role] = color.New(attrs...)
+		}
+	}
+	return compiled, nil
+}
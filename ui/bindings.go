@@ -0,0 +1,175 @@
+package ui
+
+import (
+	"ffgh/config"
+	"fmt"
+	"slices"
+)
+
+// Binding is a key binding of the fzf list.
+type Binding struct {
+	Key         string
+	Description string
+	// Action is the fzf action. The {bin} placeholder is replaced with the ffgh command.
+	Action string
+}
+
+// builtinAction is an action that can be bound to a key by its name.
+type builtinAction struct {
+	Description string
+	Action      string
+}
+
+const (
+	ActionOpen           = "open"
+	ActionMarkRead       = "mark-read"
+	ActionMarkReadOrMute = "mark-read-or-mute"
+	ActionMarkUnread     = "mark-unread"
+	ActionMute           = "mute"
+	ActionPin            = "pin"
+	ActionCycleNote      = "cycle-note"
+	ActionEditNote       = "edit-note"
+	ActionCycleView      = "cycle-view"
+	ActionCycleSort      = "cycle-sort"
+	ActionToggleDiff     = "toggle-diff"
+	ActionUndo           = "undo"
+	ActionHelp           = "help"
+)
+
+var builtinActions = map[string]builtinAction{
+	ActionOpen: {
+		Description: "Open without exiting",
+		Action:      "reload({bin} open {+1} && {bin} fzf)+down",
+	},
+	ActionMarkRead: {
+		Description: "Mark as read without opening",
+		Action:      "reload({bin} mark-open {+1} && {bin} fzf)+down",
+	},
+	ActionMarkReadOrMute: {
+		Description: "Mark as read without opening, mute and unmute",
+		Action:      "reload({bin} mark-open -e {+1} || {bin} mark-mute {+1} && {bin} fzf)+down",
+	},
+	ActionMarkUnread: {
+		Description: "Mark as unread",
+		Action:      "reload({bin} mark-unread {+1} && {bin} fzf)",
+	},
+	ActionMute: {
+		Description: "Mute and unmute",
+		Action:      "reload({bin} mark-mute {+1} && {bin} fzf)",
+	},
+	ActionPin: {
+		Description: "Pin and unpin",
+		Action:      "reload({bin} pin {+1} && {bin} fzf)",
+	},
+	ActionCycleNote: {
+		Description: "Annotate with a standard annotation",
+		Action:      "reload({bin} cycle-note {+1} && {bin} fzf)",
+	},
+	ActionEditNote: {
+		Description: "Edit the note",
+		Action:      "execute({bin} edit-note {1})+reload({bin} fzf)",
+	},
+	ActionCycleView: {
+		Description: "Cycle view mode",
+		Action:      "reload({bin} cycle-view-mode && {bin} fzf)",
+	},
+	ActionCycleSort: {
+		Description: "Cycle sort mode",
+		Action:      "reload({bin} cycle-sort && {bin} fzf)",
+	},
+	ActionToggleDiff: {
+		Description: "Toggle the diff in the preview",
+		Action:      "preview({bin} toggle-diff && {bin} show-pr {1})",
+	},
+	ActionUndo: {
+		Description: "Undo the last action",
+		Action:      "reload({bin} undo && {bin} fzf)",
+	},
+	ActionHelp: {
+		Description: "Show this help in the preview",
+		Action:      "preview({bin} help)",
+	},
+}
+
+var DefaultKeyBindings = []config.KeyBinding{
+	{Key: "ctrl-r", Action: ActionMarkReadOrMute},
+	{Key: "ctrl-v", Action: ActionCycleView},
+	{Key: "ctrl-s", Action: ActionCycleSort},
+	{Key: "alt-d", Action: ActionToggleDiff},
+	{Key: "ctrl-o", Action: ActionOpen},
+	{Key: "alt-f", Action: ActionOpen, Target: "files"},
+	{Key: "alt-k", Action: ActionOpen, Target: "checks"},
+	{Key: "alt-l", Action: ActionOpen, Target: "commits"},
+	{Key: "ctrl-a", Action: ActionCycleNote},
+	{Key: "ctrl-x", Action: ActionMarkUnread},
+	{Key: "alt-p", Action: ActionPin},
+	{Key: "alt-u", Action: ActionUndo},
+	{Key: "ctrl-n", Action: ActionEditNote},
+	{Key: "ctrl-h", Action: ActionHelp},
+}
+
+// BuildBindings returns the fzf bindings for the default key bindings overridden by the configured key bindings. A
+// configured key binding replaces the default one for the same key.
+func BuildBindings(configured []config.KeyBinding) ([]Binding, error) {
+	keyBindings := slices.Clone(DefaultKeyBindings)
+	for _, kb := range configured {
+		i := slices.IndexFunc(keyBindings, func(d config.KeyBinding) bool { return d.Key == kb.Key })
+		if i >= 0 {
+			keyBindings[i] = kb
+		} else {
+			keyBindings = append(keyBindings, kb)
+		}
+	}
+	bindings := []Binding{}
+	for _, kb := range keyBindings {
+		if kb.Action == config.ActionNone {
+			continue
+		}
+		b, err := buildBinding(kb)
+		if err != nil {
+			return nil, err
+		}
+		bindings = append(bindings, b)
+	}
+	return bindings, nil
+}
+
+func buildBinding(kb config.KeyBinding) (Binding, error) {
+	if kb.Key == "" {
+		return Binding{}, fmt.Errorf("key binding without key")
+	}
+	if kb.Command != "" {
+		description := kb.Description
+		if description == "" {
+			description = kb.Command
+		}
+		return Binding{
+			Key:         kb.Key,
+			Description: description,
+			// The command is looked up by the key, so it does not have to be escaped for fzf.
+			Action: fmt.Sprintf("execute({bin} run-binding %s {+1})+reload({bin} fzf)", kb.Key),
+		}, nil
+	}
+	action, ok := builtinActions[kb.Action]
+	if !ok {
+		return Binding{}, fmt.Errorf("unknown action %q for key %s", kb.Action, kb.Key)
+	}
+	description := kb.Description
+	if description == "" {
+		description = action.Description
+	}
+	if kb.Target != "" {
+		if kb.Action != ActionOpen {
+			return Binding{}, fmt.Errorf("target %s set for action %s, only %s has targets", kb.Target, kb.Action, ActionOpen)
+		}
+		if kb.Description == "" {
+			description = fmt.Sprintf("Open %s without exiting", kb.Target)
+		}
+		return Binding{
+			Key:         kb.Key,
+			Description: description,
+			Action:      fmt.Sprintf("reload({bin} open -t %s {+1} && {bin} fzf)+down", ShellQuote(kb.Target)),
+		}, nil
+	}
+	return Binding{Key: kb.Key, Description: description, Action: action.Action}, nil
+}
@@ -0,0 +1,18 @@
+package ui
+
+import (
+	"ffgh/gh"
+	"fmt"
+	"strings"
+)
+
+// ExpandCommand replaces the {url}, {repo} and {number} placeholders in the command of a custom key binding with the
+// shell-quoted values of the PR.
+func ExpandCommand(command string, pr gh.PullRequest) string {
+	r := strings.NewReplacer(
+		"{url}", ShellQuote(pr.URL),
+		"{repo}", ShellQuote(pr.Repository.NameWithOwner),
+		"{number}", fmt.Sprint(pr.Number),
+	)
+	return r.Replace(command)
+}
@@ -0,0 +1,63 @@
+package ui
+
+import (
+	"ffgh/config"
+	"ffgh/fzf"
+	"ffgh/theme"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// FprintHelp prints the active key bindings, the view modes and the legend of the list.
+func FprintHelp(out io.Writer, bindings []Binding, views []config.View) {
+	fmt.Fprintln(out, theme.S(theme.Header, "Key bindings"))
+	fmt.Fprintf(out, "  %-8s %s\n", "enter", "Open the selected PRs and exit")
+	fmt.Fprintf(out, "  %-8s %s\n", "tab", "Multi-select, the bindings apply to all the selected PRs")
+	for _, b := range bindings {
+		fmt.Fprintf(out, "  %-8s %s\n", b.Key, b.Description)
+	}
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, theme.S(theme.Header, "View modes"))
+	for _, v := range views {
+		fmt.Fprintf(out, "  %-10s %s\n", v.Name, describeView(v))
+	}
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, theme.S(theme.Header, "Sort modes"))
+	for _, m := range fzf.SortModes() {
+		fmt.Fprintf(out, "  %-10s %s\n", m, fzf.SortModeDescriptions[m])
+	}
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, theme.S(theme.Header, "Legend"))
+	fmt.Fprintf(out, "  %s  %s\n", theme.S(theme.Pinned, "P"), "Pinned, always at the top")
+	fmt.Fprintf(out, "  %s  %s\n", theme.S(theme.New, "N"), "New, never opened")
+	fmt.Fprintf(out, "  %s  %s\n", theme.S(theme.Updated, "U"), "Updated since last opened")
+	fmt.Fprintf(out, "  %s  %s\n", theme.S(theme.Comments, "C"), "New comments since last opened")
+	fmt.Fprintf(out, "  %s  %s\n", theme.S(theme.Note, "[]"), "Note")
+	fmt.Fprintf(out, "  %s  %s\n", theme.S(theme.Tags, "#"), "Tags")
+	fmt.Fprintf(out, "  %s  %s\n", theme.S(theme.Muted, "muted"), "Muted, not counted in xbar")
+}
+
+// describeView returns the description of the view, or the summary of its filters if there is no description.
+func describeView(v config.View) string {
+	if v.Description != "" {
+		return v.Description
+	}
+	parts := []string{}
+	add := func(name string, values []string) {
+		if len(values) > 0 {
+			parts = append(parts, fmt.Sprintf("%s: %s", name, strings.Join(values, ", ")))
+		}
+	}
+	add("queries", v.Queries)
+	add("flags", v.Flags)
+	add("repos", v.Repos)
+	add("tags", v.Tags)
+	if v.Mute != "" {
+		add("muted", []string{v.Mute})
+	}
+	if v.Sort != "" {
+		add("sort", []string{v.Sort})
+	}
+	return strings.Join(parts, "; ")
+}
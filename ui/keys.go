@@ -0,0 +1,99 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// parseKey returns the fzf name of the first key in the input, and the number of bytes it takes. Printable
+// characters are returned as they are.
+func parseKey(b []byte) (string, int) {
+	c := b[0]
+	switch {
+	case c == 27 && len(b) == 1:
+		return "esc", 1
+	case c == 27 && len(b) >= 3 && (b[1] == '[' || b[1] == 'O'):
+		n := 3
+		for n <= len(b) && !(b[n-1] >= 0x40 && b[n-1] <= 0x7e) {
+			n++
+		}
+		if n > len(b) {
+			return "", len(b)
+		}
+		switch string(b[2:n]) {
+		case "A":
+			return "up", n
+		case "B":
+			return "down", n
+		case "5~":
+			return "pgup", n
+		case "6~":
+			return "pgdn", n
+		}
+		return "", n
+	case c == 27:
+		key, n := parseKey(b[1:])
+		return "alt-" + key, n + 1
+	case c == 9:
+		return "tab", 1
+	case c == 13 || c == 10:
+		return "enter", 1
+	case c == 127:
+		return "bspace", 1
+	case c >= 1 && c <= 26:
+		return fmt.Sprintf("ctrl-%c", 'a'+c-1), 1
+	case c < 32:
+		return "", 1
+	}
+	r, n := utf8.DecodeRune(b)
+	return string(r), n
+}
+
+// fzfAction is a single action of an fzf binding, like reload(...) or down.
+type fzfAction struct {
+	Name string
+	Arg  string
+}
+
+// parseFzfActions splits the fzf binding action like "reload(cmd)+down" into single actions. The argument can be
+// given in parentheses, which can be nested, or after a colon, in which case it takes the rest of the string.
+func parseFzfActions(s string) ([]fzfAction, error) {
+	actions := []fzfAction{}
+	for s != "" {
+		i := strings.IndexAny(s, "(:+")
+		if i < 0 {
+			actions = append(actions, fzfAction{Name: s})
+			break
+		}
+		name := s[:i]
+		switch s[i] {
+		case '+':
+			actions = append(actions, fzfAction{Name: name})
+			s = s[i+1:]
+		case ':':
+			actions = append(actions, fzfAction{Name: name, Arg: s[i+1:]})
+			s = ""
+		case '(':
+			depth := 0
+			end := -1
+			for j := i; j < len(s); j++ {
+				if s[j] == '(' {
+					depth++
+				} else if s[j] == ')' {
+					depth--
+					if depth == 0 {
+						end = j
+						break
+					}
+				}
+			}
+			if end < 0 {
+				return nil, fmt.Errorf("unbalanced parentheses in action: %s", s)
+			}
+			actions = append(actions, fzfAction{Name: name, Arg: s[i+1 : end]})
+			s = strings.TrimPrefix(s[end+1:], "+")
+		}
+	}
+	return actions, nil
+}
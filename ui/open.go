@@ -0,0 +1,61 @@
+package ui
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// TargetConversation is the PR page itself.
+const TargetConversation = "conversation"
+
+// DefaultUrlTargets map the names of the PR pages to the suffixes appended to the PR URL.
+var DefaultUrlTargets = map[string]string{
+	TargetConversation: "",
+	"files":            "/files",
+	"checks":           "/checks",
+	"commits":          "/commits",
+}
+
+// DefaultOpener returns the command that opens URLs on the current platform.
+func DefaultOpener() string {
+	if runtime.GOOS == "darwin" {
+		return "open"
+	}
+	return "xdg-open"
+}
+
+// TargetUrl returns the URL of the PR page. The configured targets take precedence over the default ones.
+func TargetUrl(url, target string, configured map[string]string) (string, error) {
+	if target == "" {
+		return url, nil
+	}
+	suffix, ok := configured[target]
+	if !ok {
+		suffix, ok = DefaultUrlTargets[target]
+	}
+	if !ok {
+		return "", fmt.Errorf("unknown URL target: %s", target)
+	}
+	return strings.TrimSuffix(url, "/") + suffix, nil
+}
+
+// OpenUrls opens each of the URLs with the opener shell command.
+func OpenUrls(opener string, urls []string) error {
+	if opener == "" {
+		opener = DefaultOpener()
+	}
+	for _, url := range urls {
+		log.Printf("Open %s with %s", url, opener)
+		cmd := exec.Command("sh", "-c", opener+" "+ShellQuote(url))
+		// The open bindings run in fzf reload, where stdout is the new list, so the output of the opener goes to stderr.
+		cmd.Stdout, cmd.Stderr = os.Stderr, os.Stderr
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("error while opening %s: %w", url, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,365 @@
+package ui
+
+import (
+	"bytes"
+	"ffgh/fzf"
+	"ffgh/theme"
+	"ffgh/util"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var placeholder = regexp.MustCompile(`\{(\+?)1\}`)
+
+// picker is a minimal replacement of fzf, used when fzf is not installed. It runs the same fzf binding actions, though
+// it supports only the subset of them that the bindings use: reload, execute, preview, up and down.
+type picker struct {
+	launcher *Launcher
+	term     *terminal
+	bindings map[string]Binding
+	header   string
+	// lines are the lines of the list, the URL and the displayed part separated with a tab.
+	lines    []string
+	query    []rune
+	matched  []int
+	cursor   int
+	offset   int
+	selected map[string]bool
+	preview  []string
+	// previewShown says that the preview was set by an action and should stay until the cursor moves.
+	previewShown bool
+	width        int
+	height       int
+}
+
+// runPicker runs the built-in picker and returns the selected URLs.
+func (l *Launcher) runPicker() ([]string, error) {
+	term, err := openTerminal()
+	if err != nil {
+		return nil, err
+	}
+	defer term.close()
+	p := &picker{
+		launcher: l,
+		term:     term,
+		bindings: make(map[string]Binding),
+		selected: make(map[string]bool),
+	}
+	for _, b := range l.Bindings {
+		p.bindings[b.Key] = b
+	}
+	p.width, p.height = term.size()
+	if err := term.enterRaw(); err != nil {
+		return nil, err
+	}
+	defer term.leaveRaw()
+	if err := p.reload(l.expand(startCommand)); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, 64)
+	for {
+		p.render()
+		n, err := term.tty.Read(buf)
+		if err != nil {
+			return nil, fmt.Errorf("error while reading terminal: %w", err)
+		}
+		for in := buf[:n]; len(in) > 0; {
+			key, k := parseKey(in)
+			in = in[k:]
+			done, urls, err := p.handleKey(key)
+			if err != nil || done {
+				return urls, err
+			}
+		}
+	}
+}
+
+// handleKey returns true if the picker is done, with the selected URLs. The key bindings take precedence over the
+// editing keys, like in fzf, so ctrl-n and ctrl-h, bound by default, are not used for moving down and backspace.
+func (p *picker) handleKey(key string) (bool, []string, error) {
+	if b, ok := p.bindings[key]; ok {
+		return false, nil, p.runActions(p.launcher.expand(b.Action))
+	}
+	switch key {
+	case "enter":
+		return true, p.targetUrls(), nil
+	case "esc", "ctrl-c", "ctrl-g", "ctrl-q":
+		return true, nil, nil
+	case "up", "ctrl-k", "ctrl-p":
+		p.move(-1)
+	case "down", "ctrl-j":
+		p.move(1)
+	case "pgup":
+		p.move(-p.listHeight())
+	case "pgdn":
+		p.move(p.listHeight())
+	case "tab":
+		if url := p.currentUrl(); url != "" {
+			p.selected[url] = !p.selected[url]
+		}
+		p.move(1)
+	case "bspace":
+		if len(p.query) > 0 {
+			p.query = p.query[:len(p.query)-1]
+			p.filter()
+		}
+	case "ctrl-u":
+		p.query = nil
+		p.filter()
+	default:
+		if r, _ := utf8.DecodeRuneInString(key); utf8.RuneCountInString(key) == 1 && unicode.IsPrint(r) {
+			p.query = append(p.query, r)
+			p.filter()
+		}
+	}
+	return false, nil, nil
+}
+
+func (p *picker) runActions(action string) error {
+	actions, err := parseFzfActions(action)
+	if err != nil {
+		return err
+	}
+	for _, a := range actions {
+		switch a.Name {
+		case "reload":
+			if err := p.reload(p.expandPlaceholders(a.Arg)); err != nil {
+				return err
+			}
+		case "execute":
+			if err := p.execute(p.expandPlaceholders(a.Arg)); err != nil {
+				return err
+			}
+		case "preview":
+			out, _ := p.output(p.expandPlaceholders(a.Arg))
+			p.preview = strings.Split(out, "\n")
+			p.previewShown = true
+		case "up":
+			p.move(-1)
+		case "down":
+			p.move(1)
+		default:
+			log.Printf("Action not supported by the built-in picker: %s", a.Name)
+		}
+	}
+	return nil
+}
+
+// expandPlaceholders replaces {1} with the URL under the cursor and {+1} with the selected URLs.
+func (p *picker) expandPlaceholders(command string) string {
+	return placeholder.ReplaceAllStringFunc(command, func(m string) string {
+		urls := []string{p.currentUrl()}
+		if strings.HasPrefix(m, "{+") {
+			urls = p.targetUrls()
+		}
+		quoted := []string{}
+		for _, u := range urls {
+			quoted = append(quoted, ShellQuote(u))
+		}
+		return strings.Join(quoted, " ")
+	})
+}
+
+func (p *picker) reload(command string) error {
+	out, err := p.output(command)
+	if err != nil {
+		log.Printf("Reload failed, keep the list: %s", err)
+		return nil
+	}
+	lines := strings.Split(strings.TrimRight(out, "\n"), "\n")
+	p.header, p.lines = lines[0], lines[1:]
+	p.filter()
+	return nil
+}
+
+// execute runs the command with the terminal restored, as fzf does.
+func (p *picker) execute(command string) error {
+	if err := p.term.leaveRaw(); err != nil {
+		return err
+	}
+	cmd := exec.Command("sh", "-c", command)
+	cmd.Env = p.env()
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = p.term.tty, p.term.tty, p.term.tty
+	if err := cmd.Run(); err != nil {
+		log.Printf("Error while running %s: %s", command, err)
+	}
+	p.width, p.height = p.term.size()
+	return p.term.enterRaw()
+}
+
+func (p *picker) output(command string) (string, error) {
+	log.Printf("Run %s", command)
+	cmd := exec.Command("sh", "-c", command)
+	cmd.Env = p.env()
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	err := cmd.Run()
+	return out.String(), err
+}
+
+func (p *picker) env() []string {
+	// The list is narrower than the terminal by the cursor and selection markers, the preview takes the whole width.
+	return append(os.Environ(), fmt.Sprintf("TERMINAL_WIDTH=%d", p.width-2), fmt.Sprintf("FZF_PREVIEW_COLUMNS=%d", p.width))
+}
+
+// filter keeps the lines that contain the characters of the query, in order and ignoring the case.
+func (p *picker) filter() {
+	current := p.currentUrl()
+	query := []rune(strings.ToLower(string(p.query)))
+	p.matched = []int{}
+	for i, line := range p.lines {
+		if fuzzyMatch(query, strings.ToLower(displayText(line))) {
+			p.matched = append(p.matched, i)
+		}
+	}
+	p.cursor = 0
+	for i, j := range p.matched {
+		if urlOf(p.lines[j]) == current {
+			p.cursor = i
+		}
+	}
+	p.move(0)
+}
+
+func fuzzyMatch(query []rune, text string) bool {
+	for _, r := range text {
+		if len(query) == 0 {
+			break
+		}
+		if r == query[0] {
+			query = query[1:]
+		}
+	}
+	return len(query) == 0
+}
+
+func (p *picker) move(delta int) {
+	previous := p.cursor
+	p.cursor = max(0, min(p.cursor+delta, len(p.matched)-1))
+	// The lines that are not PRs, like the group headers, are skipped.
+	step := 1
+	if delta < 0 {
+		step = -1
+	}
+	for i := p.cursor; i >= 0 && i < len(p.matched); i += step {
+		if urlOf(p.lines[p.matched[i]]) != "" {
+			p.cursor = i
+			break
+		}
+	}
+	if h := p.listHeight(); p.cursor < p.offset {
+		p.offset = p.cursor
+	} else if p.cursor >= p.offset+h {
+		p.offset = p.cursor - h + 1
+	}
+	if previous != p.cursor || !p.previewShown {
+		p.previewShown = false
+		p.updatePreview()
+	}
+}
+
+func (p *picker) updatePreview() {
+	url := p.currentUrl()
+	if url == "" {
+		p.preview = nil
+		return
+	}
+	out, _ := p.output(p.launcher.expand(strings.ReplaceAll(previewCommand, "{1}", ShellQuote(url))))
+	p.preview = strings.Split(out, "\n")
+}
+
+func (p *picker) currentUrl() string {
+	if p.cursor < 0 || p.cursor >= len(p.matched) {
+		return ""
+	}
+	return urlOf(p.lines[p.matched[p.cursor]])
+}
+
+// targetUrls returns the selected URLs, or the URL under the cursor if nothing is selected.
+func (p *picker) targetUrls() []string {
+	urls := []string{}
+	for _, line := range p.lines {
+		if url := urlOf(line); p.selected[url] {
+			urls = append(urls, url)
+		}
+	}
+	if len(urls) == 0 && p.currentUrl() != "" {
+		urls = append(urls, p.currentUrl())
+	}
+	return urls
+}
+
+func (p *picker) previewHeight() int {
+	return (p.height - 3) / 2
+}
+
+// listHeight is the height of the screen without the preview, the separator, the prompt and the header.
+func (p *picker) listHeight() int {
+	return max(1, p.height-p.previewHeight()-3)
+}
+
+func (p *picker) render() {
+	p.width, p.height = p.term.size()
+	var b strings.Builder
+	b.WriteString("\x1b[H")
+	writeLine := func(s string) {
+		b.WriteString("\x1b[2K" + s + "\x1b[0m\r\n")
+	}
+	for i := 0; i < p.previewHeight(); i++ {
+		line := ""
+		if i < len(p.preview) {
+			line = p.preview[i]
+		}
+		writeLine(line)
+	}
+	writeLine(strings.Repeat("─", p.width))
+	writeLine(fmt.Sprintf("> %s\x1b[7m \x1b[0m  %d/%d", string(p.query), len(p.matched), len(p.lines)))
+	writeLine("  " + displayText(p.header))
+	for i := 0; i < p.listHeight(); i++ {
+		j := p.offset + i
+		if j >= len(p.matched) {
+			writeLine("")
+			continue
+		}
+		line := p.lines[p.matched[j]]
+		gutter := " "
+		if j == p.cursor {
+			gutter = theme.S(theme.Cursor, ">")
+		}
+		mark := " "
+		if p.selected[urlOf(line)] {
+			mark = theme.S(theme.Selected, "*")
+		}
+		writeLine(gutter + mark + displayPart(line))
+	}
+	out := strings.TrimSuffix(b.String(), "\r\n")
+	p.term.tty.WriteString(out)
+}
+
+func urlOf(line string) string {
+	url, _, _ := strings.Cut(line, "\t")
+	url = util.StripAnsi(url)
+	if url == fzf.NoUrl {
+		return ""
+	}
+	return url
+}
+
+// displayPart is the part of the line shown to the user, that is all but the first field, like --with-nth=2.. of fzf.
+func displayPart(line string) string {
+	i := strings.IndexAny(line, " \t")
+	if i < 0 {
+		return line
+	}
+	return line[i+1:]
+}
+
+func displayText(line string) string {
+	return util.StripAnsi(displayPart(line))
+}
@@ -0,0 +1,65 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// terminal is the controlling terminal switched to raw mode, used by the built-in picker.
+type terminal struct {
+	tty      *os.File
+	original *unix.Termios
+}
+
+func openTerminal() (*terminal, error) {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return nil, fmt.Errorf("error while opening terminal: %w", err)
+	}
+	original, err := unix.IoctlGetTermios(int(tty.Fd()), ioctlGetTermios)
+	if err != nil {
+		tty.Close()
+		return nil, fmt.Errorf("error while reading terminal state: %w", err)
+	}
+	return &terminal{tty: tty, original: original}, nil
+}
+
+// enterRaw switches the terminal to raw mode and to the alternate screen.
+func (t *terminal) enterRaw() error {
+	raw := *t.original
+	raw.Iflag &^= unix.IGNBRK | unix.BRKINT | unix.PARMRK | unix.ISTRIP | unix.INLCR | unix.IGNCR | unix.ICRNL | unix.IXON
+	raw.Lflag &^= unix.ECHO | unix.ECHONL | unix.ICANON | unix.ISIG | unix.IEXTEN
+	raw.Cflag &^= unix.CSIZE | unix.PARENB
+	raw.Cflag |= unix.CS8
+	raw.Cc[unix.VMIN] = 1
+	raw.Cc[unix.VTIME] = 0
+	if err := unix.IoctlSetTermios(int(t.tty.Fd()), ioctlSetTermios, &raw); err != nil {
+		return fmt.Errorf("error while setting raw mode: %w", err)
+	}
+	// Alternate screen, hidden cursor, no line wrapping.
+	_, err := t.tty.WriteString("\x1b[?1049h\x1b[?25l\x1b[?7l")
+	return err
+}
+
+// leaveRaw restores the original state of the terminal.
+func (t *terminal) leaveRaw() error {
+	t.tty.WriteString("\x1b[?7h\x1b[?25h\x1b[?1049l")
+	if err := unix.IoctlSetTermios(int(t.tty.Fd()), ioctlSetTermios, t.original); err != nil {
+		return fmt.Errorf("error while restoring terminal: %w", err)
+	}
+	return nil
+}
+
+func (t *terminal) size() (int, int) {
+	ws, err := unix.IoctlGetWinsize(int(t.tty.Fd()), unix.TIOCGWINSZ)
+	if err != nil || ws.Col == 0 || ws.Row == 0 {
+		return defaultTerminalWidth, 24
+	}
+	return int(ws.Col), int(ws.Row)
+}
+
+func (t *terminal) close() error {
+	return t.tty.Close()
+}
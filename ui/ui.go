@@ -0,0 +1,130 @@
+package ui
+
+import (
+	"bytes"
+	"errors"
+	"ffgh/fzf"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	defaultTerminalWidth = 120
+	startCommand         = "{bin} fzf"
+	previewCommand       = "{bin} show-pr {1}"
+)
+
+// Launcher runs fzf with the list of PRs and opens the selected PRs. If fzf is not installed, it runs the built-in
+// picker with the same bindings.
+type Launcher struct {
+	// Bin is the shell command that runs ffgh, including the global flags.
+	Bin      string
+	Bindings []Binding
+	// Builtin forces the built-in picker even if fzf is installed.
+	Builtin bool
+	// Open is called with the selected URLs when fzf exits.
+	Open func(urls []string) error
+}
+
+func New(bin string, bindings []Binding, open func(urls []string) error) *Launcher {
+	return &Launcher{
+		Bin:      bin,
+		Bindings: bindings,
+		Open:     open,
+	}
+}
+
+func (l *Launcher) Run() error {
+	if _, err := exec.LookPath("fzf"); err != nil || l.Builtin {
+		log.Printf("Use the built-in picker, fzf lookup: %v", err)
+		urls, err := l.runPicker()
+		if err != nil || len(urls) == 0 {
+			return err
+		}
+		return l.Open(urls)
+	}
+	args := l.FzfArgs()
+	log.Printf("Run fzf %s", strings.Join(args, " "))
+	cmd := exec.Command("fzf", args...)
+	cmd.Env = append(os.Environ(), fmt.Sprintf("TERMINAL_WIDTH=%d", getTerminalWidth()))
+	cmd.Stdin = os.Stdin
+	cmd.Stderr = os.Stderr
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		// fzf exits with 1 when there is no match and 130 when interrupted, which is not an error for the user.
+		if errors.As(err, &exitErr) && (exitErr.ExitCode() == 1 || exitErr.ExitCode() == 130) {
+			log.Printf("fzf exited with %d", exitErr.ExitCode())
+			return nil
+		}
+		return fmt.Errorf("error while running fzf: %w", err)
+	}
+	urls := selectedUrls(out.String())
+	if len(urls) == 0 {
+		return nil
+	}
+	return l.Open(urls)
+}
+
+// FzfArgs returns the arguments of fzf, with the bindings expanded.
+func (l *Launcher) FzfArgs() []string {
+	args := []string{
+		"--ansi",
+		"--multi",
+		"--with-nth=2..",
+		"--preview-window=top:wrap",
+		"--preview", l.expand(previewCommand),
+		"--header-lines=1",
+		"--bind", l.expand("start:reload(" + startCommand + ")"),
+	}
+	for _, b := range l.Bindings {
+		args = append(args, "--bind", b.Key+":"+l.expand(b.Action))
+	}
+	return args
+}
+
+func (l *Launcher) expand(action string) string {
+	return strings.ReplaceAll(action, "{bin}", l.Bin)
+}
+
+// selectedUrls returns the URLs from the fzf output, that is the first tab-separated field of each line.
+func selectedUrls(out string) []string {
+	urls := []string{}
+	for _, line := range strings.Split(out, "\n") {
+		url, _, _ := strings.Cut(strings.TrimSpace(line), "\t")
+		if url != "" && url != fzf.NoUrl {
+			urls = append(urls, url)
+		}
+	}
+	return urls
+}
+
+func getTerminalWidth() int {
+	if v, err := strconv.Atoi(os.Getenv("TERMINAL_WIDTH")); err == nil {
+		return v
+	}
+	tty, err := os.Open("/dev/tty")
+	if err != nil {
+		log.Printf("Could not open terminal, use default width: %s", err)
+		return defaultTerminalWidth
+	}
+	defer tty.Close()
+	ws, err := unix.IoctlGetWinsize(int(tty.Fd()), unix.TIOCGWINSZ)
+	if err != nil {
+		log.Printf("Could not get terminal size, use default width: %s", err)
+		return defaultTerminalWidth
+	}
+	return int(ws.Col)
+}
+
+// ShellQuote quotes the string so it is passed to the shell as a single word.
+func ShellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'"'"'`) + "'"
+}
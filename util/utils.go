@@ -13,3 +13,10 @@ func Cycle(curr string, values []string) string {
 		return values[0]
 	}
 }
+
+func BoolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,172 @@
+package util
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var ansiEscape = regexp.MustCompile("\x1b\\[[0-9;?]*[ -/]*[@-~]")
+
+// wideRanges are the ranges of East Asian wide and fullwidth characters and of the emoji, that take two cells of the
+// terminal.
+var wideRanges = []struct{ lo, hi rune }{
+	{0x1100, 0x115F},
+	{0x231A, 0x231B},
+	{0x2329, 0x232A},
+	{0x23E9, 0x23EC},
+	{0x23F0, 0x23F0},
+	{0x23F3, 0x23F3},
+	{0x25FD, 0x25FE},
+	{0x2614, 0x2615},
+	{0x2648, 0x2653},
+	{0x267F, 0x267F},
+	{0x2693, 0x2693},
+	{0x26A1, 0x26A1},
+	{0x26AA, 0x26AB},
+	{0x26BD, 0x26BE},
+	{0x26C4, 0x26C5},
+	{0x26CE, 0x26CE},
+	{0x26D4, 0x26D4},
+	{0x26EA, 0x26EA},
+	{0x26F2, 0x26F3},
+	{0x26F5, 0x26F5},
+	{0x26FA, 0x26FA},
+	{0x26FD, 0x26FD},
+	{0x2705, 0x2705},
+	{0x270A, 0x270B},
+	{0x2728, 0x2728},
+	{0x274C, 0x274C},
+	{0x274E, 0x274E},
+	{0x2753, 0x2755},
+	{0x2757, 0x2757},
+	{0x2795, 0x2797},
+	{0x27B0, 0x27B0},
+	{0x27BF, 0x27BF},
+	{0x2B1B, 0x2B1C},
+	{0x2B50, 0x2B50},
+	{0x2B55, 0x2B55},
+	{0x2E80, 0x303E},
+	{0x3041, 0x33FF},
+	{0x3400, 0x4DBF},
+	{0x4E00, 0x9FFF},
+	{0xA000, 0xA4CF},
+	{0xA960, 0xA97F},
+	{0xAC00, 0xD7A3},
+	{0xF900, 0xFAFF},
+	{0xFE10, 0xFE19},
+	{0xFE30, 0xFE6F},
+	{0xFF00, 0xFF60},
+	{0xFFE0, 0xFFE6},
+	{0x16FE0, 0x16FE4},
+	{0x17000, 0x18AFF},
+	{0x1B000, 0x1B2FF},
+	{0x1F004, 0x1F004},
+	{0x1F0CF, 0x1F0CF},
+	{0x1F18E, 0x1F18E},
+	{0x1F191, 0x1F19A},
+	{0x1F200, 0x1F251},
+	{0x1F300, 0x1F64F},
+	{0x1F680, 0x1F6FF},
+	{0x1F7E0, 0x1F7EB},
+	{0x1F90C, 0x1F9FF},
+	{0x1FA70, 0x1FAFF},
+	{0x20000, 0x2FFFD},
+	{0x30000, 0x3FFFD},
+}
+
+// RuneWidth returns the number of terminal cells the rune takes: 0 for combining and control characters, 2 for wide
+// characters and 1 otherwise.
+func RuneWidth(r rune) int {
+	if r < 0x20 || (r >= 0x7F && r < 0xA0) || r == 0x200D ||
+		unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf) {
+		return 0
+	}
+	if r < 0x1100 {
+		return 1
+	}
+	for _, w := range wideRanges {
+		if r < w.lo {
+			break
+		}
+		if r <= w.hi {
+			return 2
+		}
+	}
+	return 1
+}
+
+const (
+	zeroWidthJoiner = '\u200D'
+	emojiVariation  = '\uFE0F'
+)
+
+// runeWidthAfter returns the width of the rune that follows the previous rune. The rune joined to the previous one
+// with the zero width joiner, like in the family emoji, is a part of the same glyph, and the emoji variation selector
+// makes the previous character take two cells.
+func runeWidthAfter(previous, r rune) int {
+	if previous == zeroWidthJoiner {
+		return 0
+	}
+	if r == emojiVariation && RuneWidth(previous) == 1 {
+		return 1
+	}
+	return RuneWidth(r)
+}
+
+// StripAnsi removes the ANSI escape sequences, like colors, from the string.
+func StripAnsi(s string) string {
+	return ansiEscape.ReplaceAllString(s, "")
+}
+
+// StringWidth returns the number of terminal cells the string takes, not counting the ANSI escape sequences.
+func StringWidth(s string) int {
+	width := 0
+	previous := rune(0)
+	for _, r := range StripAnsi(s) {
+		width += runeWidthAfter(previous, r)
+		previous = r
+	}
+	return width
+}
+
+// TruncateWidth cuts the string to at most width terminal cells, never splitting a wide character or a joined emoji.
+// The ANSI escape sequences are kept, and the styles are reset at the end if the string was cut.
+func TruncateWidth(s string, width int) string {
+	if StringWidth(s) <= width {
+		return s
+	}
+	var b strings.Builder
+	w := 0
+	styled := false
+	previous := rune(0)
+	for len(s) > 0 {
+		if s[0] == '\x1b' {
+			if loc := ansiEscape.FindStringIndex(s); loc != nil && loc[0] == 0 {
+				b.WriteString(s[:loc[1]])
+				s = s[loc[1]:]
+				styled = true
+				continue
+			}
+		}
+		r, size := utf8.DecodeRuneInString(s)
+		rw := runeWidthAfter(previous, r)
+		if w+rw > width {
+			break
+		}
+		b.WriteRune(r)
+		w += rw
+		previous = r
+		s = s[size:]
+	}
+	if styled {
+		b.WriteString("\x1b[0m")
+	}
+	return b.String()
+}
+
+// PadWidth pads the string with spaces on the right to the width in terminal cells.
+func PadWidth(s string, width int) string {
+	return s + strings.Repeat(" ", max(0, width-StringWidth(s)))
+}
@@ -0,0 +1,83 @@
+package util
+
+import "testing"
+
+func TestStringWidth(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		want int
+	}{
+		{"empty", "", 0},
+		{"ascii", "abc", 3},
+		{"cjk", "日本語", 6},
+		{"mixed", "a日b", 4},
+		{"ansi", "\x1b[31mred\x1b[0m", 3},
+		{"ansi cjk", "\x1b[1;32m日本\x1b[0m", 4},
+		{"combining mark", "e\u0301", 1},
+		{"emoji", "👍", 2},
+		{"emoji zwj", "👨‍👩‍👧", 2},
+		{"emoji vs16", "❤️", 2},
+		{"fullwidth", "ＡＢ", 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := StringWidth(tt.s); got != tt.want {
+				t.Errorf("StringWidth(%q) = %d, want %d", tt.s, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTruncateWidth(t *testing.T) {
+	tests := []struct {
+		name  string
+		s     string
+		width int
+		want  string
+	}{
+		{"fits", "abc", 5, "abc"},
+		{"exact", "abc", 3, "abc"},
+		{"ascii", "abcdef", 3, "abc"},
+		{"cjk boundary", "日本語", 4, "日本"},
+		{"inside wide character", "日本語", 3, "日"},
+		{"inside first wide character", "日本語", 1, ""},
+		{"combining mark kept", "e\u0301xyz", 1, "e\u0301"},
+		{"emoji zwj kept whole", "👨‍👩‍👧abc", 3, "👨‍👩‍👧a"},
+		{"emoji zwj not split", "a👨‍👩‍👧", 2, "a"},
+		{"emoji vs16", "❤️abc", 3, "❤️a"},
+		{"ansi reset", "\x1b[31mabcdef\x1b[0m", 2, "\x1b[31mab\x1b[0m"},
+		{"ansi inside wide character", "\x1b[31m日本語\x1b[0m", 3, "\x1b[31m日\x1b[0m"},
+		{"ansi fits", "\x1b[31mab\x1b[0m", 2, "\x1b[31mab\x1b[0m"},
+		{"zero width", "abc", 0, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := TruncateWidth(tt.s, tt.width); got != tt.want {
+				t.Errorf("TruncateWidth(%q, %d) = %q, want %q", tt.s, tt.width, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPadWidth(t *testing.T) {
+	tests := []struct {
+		name  string
+		s     string
+		width int
+		want  string
+	}{
+		{"ascii", "ab", 4, "ab  "},
+		{"cjk", "日本", 6, "日本  "},
+		{"ansi", "\x1b[31mab\x1b[0m", 4, "\x1b[31mab\x1b[0m  "},
+		{"emoji zwj", "👨‍👩‍👧", 3, "👨‍👩‍👧 "},
+		{"wider", "abcdef", 3, "abcdef"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PadWidth(tt.s, tt.width); got != tt.want {
+				t.Errorf("PadWidth(%q, %d) = %q, want %q", tt.s, tt.width, got, tt.want)
+			}
+		})
+	}
+}
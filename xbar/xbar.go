@@ -12,6 +12,7 @@ import (
 func FprintCompactSummary(out io.Writer, prs []gh.PullRequest, userState *storage.UserState) {
 	newCount := 0
 	updatedCount := 0
+	// commentedCount is the number of new comments, in all the PRs.
 	commentedCount := 0
 	totalCount := 0
 	for _, pr := range prs {
@@ -26,9 +27,8 @@ func FprintCompactSummary(out io.Writer, prs []gh.PullRequest, userState *storag
 			newCount++
 		case flags&storage.IS_UPDATED != 0:
 			updatedCount++
-		case flags&storage.HAS_NEW_COMMENTS != 0:
-			commentedCount++
 		}
+		commentedCount += storage.NewCommentCount(pr, prState)
 	}
 	parts := []string{}
 	parts = append(parts, fmt.Sprintf("GH%d", totalCount))